@@ -0,0 +1,195 @@
+package jishell
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SetAlias defines a shortcut for a shell line, e.g. "use cdn; setf sl a,b; run".
+// The body may reference the alias arguments with $1..$9 and $@. If it contains
+// no placeholder, the arguments are appended to the body.
+// Aliases take precedence over commands with the same name.
+func (a *App) SetAlias(name, body string) error {
+	if !varNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid alias name: %s", name)
+	} else if len(strings.TrimSpace(body)) == 0 {
+		return fmt.Errorf("empty alias: %s", name)
+	}
+	if a.aliases == nil {
+		a.aliases = make(map[string]string)
+	}
+	a.aliases[name] = body
+	return a.autoSaveAliases()
+}
+
+// UnsetAlias removes an alias.
+func (a *App) UnsetAlias(name string) error {
+	if _, ok := a.aliases[name]; !ok {
+		return fmt.Errorf("alias %s not found", name)
+	}
+	delete(a.aliases, name)
+	return a.autoSaveAliases()
+}
+
+// Aliases returns a copy of all aliases.
+func (a *App) Aliases() map[string]string {
+	m := make(map[string]string, len(a.aliases))
+	for k, v := range a.aliases {
+		m[k] = v
+	}
+	return m
+}
+
+// alias returns the body of the alias, if the name is an alias
+// and not already being expanded.
+func (a *App) alias(name string) (string, bool) {
+	if a.expanding[name] {
+		return "", false
+	}
+	body, ok := a.aliases[name]
+	return body, ok
+}
+
+// runAlias executes the alias body as shell line with the arguments
+// and the input and output of the pipe, the alias is part of.
+// The alias is not expanded again within its own body.
+func (a *App) runAlias(name, body string, args []string, stdin io.Reader, stdout io.Writer) error {
+	if a.expanding == nil {
+		a.expanding = make(map[string]bool)
+	}
+	a.expanding[name] = true
+	defer delete(a.expanding, name)
+
+	return a.execLineIO(expandAliasArgs(body, unquoteArgs(args)), stdin, stdout)
+}
+
+// expandAliasArgs replaces the placeholders $1..$9 and $@ in the alias body
+// by the arguments. Single quoted text is kept literally.
+// Each argument is quoted as one word, so that its value is not parsed again,
+// e.g. for operators or variables.
+func expandAliasArgs(body string, args []string) string {
+	var (
+		b     strings.Builder
+		quote byte
+		found bool
+	)
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '\\' && quote != '\'' && i+1 < len(body):
+			b.WriteByte(c)
+			b.WriteByte(body[i+1])
+			i++
+			continue
+		case c == '\'' && quote != '"':
+			if quote == 0 {
+				quote = c
+			} else {
+				quote = 0
+			}
+		case c == '"' && quote != '\'':
+			if quote == 0 {
+				quote = c
+			} else {
+				quote = 0
+			}
+		case c == '$' && quote != '\'' && i+1 < len(body):
+			next := body[i+1]
+			if next == '@' {
+				b.WriteString(quoteAliasArgs(args, quote))
+				found = true
+				i++
+				continue
+			} else if next >= '1' && next <= '9' {
+				if n := int(next - '1'); n < len(args) {
+					b.WriteString(quoteAliasArg(args[n], quote))
+				}
+				found = true
+				i++
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	if !found && len(args) > 0 {
+		b.WriteString(" " + quoteAliasArgs(args, 0))
+	}
+	return b.String()
+}
+
+// quoteAliasArg single quotes the argument. Inside double quotes of the body,
+// the double quotes are closed before and opened again after the argument.
+func quoteAliasArg(arg string, quote byte) string {
+	arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	if quote == '"' {
+		return `"` + arg + `"`
+	}
+	return arg
+}
+
+func quoteAliasArgs(args []string, quote byte) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteAliasArg(arg, quote)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// sortedAliasNames returns the names of all aliases in alphabetical order.
+func (a *App) sortedAliasNames() []string {
+	names := make([]string, 0, len(a.aliases))
+	for k := range a.aliases {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadAliases reads the aliases from the file.
+func (a *App) loadAliases(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var aliases map[string]string
+	err = json.Unmarshal(data, &aliases)
+	if err != nil {
+		return fmt.Errorf("invalid alias file %s: %v", path, err)
+	}
+	a.aliases = aliases
+	return nil
+}
+
+// saveAliases writes the aliases to the file.
+func (a *App) saveAliases(path string) error {
+	data, err := json.MarshalIndent(a.Aliases(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// autoLoadAliases loads the alias file configured in the config, if present.
+func (a *App) autoLoadAliases() error {
+	if len(a.config.AliasFile) == 0 {
+		return nil
+	}
+	err := a.loadAliases(a.config.AliasFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// autoSaveAliases writes the aliases to the alias file configured in the config.
+func (a *App) autoSaveAliases() error {
+	if len(a.config.AliasFile) == 0 {
+		return nil
+	}
+	return a.saveAliases(a.config.AliasFile)
+}
@@ -25,11 +25,14 @@
 package jishell
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/chroblert/jishell/jconfig"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"io"
 	"os"
+	"os/signal"
 	"reflect"
 	"strings"
 
@@ -37,6 +40,7 @@ import (
 	"github.com/desertbit/closer/v3"
 	"github.com/desertbit/readline"
 	"github.com/fatih/color"
+	"github.com/spf13/viper"
 )
 
 // App is the entrypoint.
@@ -67,6 +71,29 @@ type App struct {
 	//currentCommandStr string
 	currentCmd  *Command // JC 220520 存放当前的Command，初始为nil
 	previousCmd *Command // JC 220520 存放use切换前的Command，初始为nil
+
+	hasPreviousCmd bool // previousCmd has been set, nil is the root then
+
+	globals map[string]string // global option values set by setg
+
+	resourceDepth int // nesting level of executed resource files
+	jobs          *jobList
+	out           io.Writer // output of the running command, the terminal if nil
+
+	vars    map[string]string // user variables set by let
+	lastErr error             // result of the previous command, $?
+	lastArg string            // last argument of the previous command, $_
+
+	aliases   map[string]string // user defined aliases
+	expanding map[string]bool   // aliases currently being expanded
+
+	history []historyEntry // lines of the shell history
+	spool   *spooler       // transcript of the shell session
+
+	configFile *viper.Viper // flag values of the config file
+	output     string       // output format of the command results
+	query      string       // gjson path applied to the command results in direct mode
+	results    *resultStore // results of the commands referenced by $results[n]
 }
 
 // New creates a new app.
@@ -90,6 +117,9 @@ func New(c *Config) (a *App) {
 		interruptHandler: defaultInterruptHandler,
 		//currentCommandStr: c.CurrentCmdStr,
 		currentCmd: nil,
+		jobs:       &jobList{},
+		spool:      &spooler{},
+		results:    &resultStore{},
 	}
 
 	// Register the builtin flags.
@@ -97,6 +127,9 @@ func New(c *Config) (a *App) {
 	a.flags.BoolL("nocolor", false, "disable color output")
 	a.flags.Bool("i", "interactive", false, "enable interactive mode")
 	a.flags.BoolL("debug", false, "display detail message.eg,flags and args")
+	a.flags.String("r", "resource", "", "execute the resource file before the first prompt, implies -i")
+	a.flags.StringL("output", OutputTable, "output format of the command results", Choices(outputFormats...))
+	a.flags.StringL("query", "", "select a part of the command results by a gjson path, e.g. hosts.#.ip")
 
 	// Register the user flags, if present.
 	if c.Flags != nil {
@@ -221,21 +254,40 @@ func (a *App) Write(p []byte) (int, error) {
 }
 
 // Stdout returns a writer to Stdout, using readline if available.
+// While a command runs with redirected output, the redirect target is returned.
+// Background jobs write to their output buffer instead.
 // Note that calling before Run() will return a different instance.
 func (a *App) Stdout() io.Writer {
+	if a.out != nil {
+		return a.out
+	}
+	return a.terminal()
+}
+
+// terminal returns a writer to the terminal, ignoring any output redirection.
+// The output is copied to the spool file, if spooling is active.
+func (a *App) terminal() io.Writer {
+	var w io.Writer = os.Stdout
 	if a.rl != nil {
-		return a.rl.Stdout()
+		w = a.rl.Stdout()
+	}
+	if len(a.spool.active()) > 0 {
+		return io.MultiWriter(w, a.spool)
 	}
-	return os.Stdout
+	return w
 }
 
 // Stderr returns a writer to Stderr, using readline if available.
 // Note that calling before Run() will return a different instance.
 func (a *App) Stderr() io.Writer {
+	var w io.Writer = os.Stderr
 	if a.rl != nil {
-		return a.rl.Stderr()
+		w = a.rl.Stderr()
 	}
-	return os.Stderr
+	if len(a.spool.active()) > 0 {
+		return io.MultiWriter(w, a.spool)
+	}
+	return w
 }
 
 // AddCommand adds a new command.
@@ -261,6 +313,89 @@ func (a *App) addCommand(cmd *Command, addHelpFlag bool) {
 
 // RunCommand runs a single command.
 func (a *App) RunCommand(args []string) error {
+	return a.runCommand(args, nil, nil)
+}
+
+// runCommand runs a single command with the input of a pipe and
+// the output redirected to stdout. Nil values keep the current input and output.
+func (a *App) runCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	// The Print functions of the app write to the output of the command as well.
+	if stdout != nil {
+		prev := a.out
+		a.out = stdout
+		defer func() { a.out = prev }()
+	}
+
+	// Aliases take precedence over commands.
+	if body, ok := a.alias(firstArg(args)); ok {
+		return a.runAlias(args[0], body, args[1:], stdin, stdout)
+	}
+
+	ctx, err := a.commandContext(args)
+	if err != nil || ctx == nil {
+		return err
+	}
+	ctx.stdin = stdin
+	ctx.stdout = stdout
+
+	// Run the command.
+	// Builtin commands like run handle interrupts of the commands they execute.
+	if ctx.Command.isBuiltin {
+		return ctx.Command.Run(ctx)
+	}
+	return a.runForeground(ctx)
+}
+
+// runForeground runs the command and cancels its context on Ctrl-C
+// according to the interrupt policy of the command.
+func (a *App) runForeground(ctx *Context) error {
+	var cancel context.CancelFunc
+	ctx.ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+
+	// The interrupt handler of the app is only called at the idle prompt,
+	// a running command is never killed.
+	done := make(chan struct{})
+	exit := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-sig:
+			}
+			switch ctx.Command.Interrupt {
+			case InterruptIgnore:
+				continue
+			case InterruptExit:
+				select {
+				case <-exit:
+				default:
+					close(exit)
+				}
+			}
+			cancel()
+			fmt.Fprintln(a.terminal(), "interrupted, waiting for the command to stop")
+		}
+	}()
+
+	err := ctx.Command.Run(ctx)
+	close(done)
+	select {
+	case <-exit:
+		return ErrInterruptExit
+	default:
+		return err
+	}
+}
+
+// commandContext parses the args and creates the context to run the command.
+// The returned context is nil, if the command help has been printed instead.
+func (a *App) commandContext(args []string) (*Context, error) {
 	// Parse the arguments string and obtain the command path to the root,
 	// and the command flags.
 	var (
@@ -285,9 +420,9 @@ func (a *App) RunCommand(args []string) error {
 		cmds, flags, args, err = tmpCommands.parse(args, a.flagMap, false)
 	}
 	if err != nil {
-		return err
+		return nil, err
 	} else if len(cmds) == 0 {
-		return fmt.Errorf("unknown command, try 'help'")
+		return nil, fmt.Errorf("unknown command, try 'help'")
 	}
 
 	// The last command is the final command.
@@ -296,7 +431,7 @@ func (a *App) RunCommand(args []string) error {
 	// Print the command help if the command run function is nil or if the help flag is set.
 	if flags.Bool("help") || cmd.Run == nil {
 		a.printCommandHelp(a, cmd, a.isShell, len(args) > 0 || len(flags) > 0)
-		return nil
+		return nil, nil
 	}
 	//jlog.Warn("runCommand:",cmd.Name,cmd.isBuiltin)
 	//jlog.Error("args:",len(args),args)
@@ -305,33 +440,49 @@ func (a *App) RunCommand(args []string) error {
 		for k, v := range args {
 			splitArgs, err := shlex.Split(v, true, false)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			args[k] = splitArgs[0]
 		}
 		// 处理flag的双引号
+		// Only values of the command line are quoted, values of the environment,
+		// the config file and the defaults are taken as they are.
 		for k, v := range flags {
 			//jlog.Info(reflect.TypeOf(v).Kind().String())
-			if reflect.TypeOf(v.Value).Kind().String() == "string" {
+			if v.Source != SourceCLI {
+				continue
+			}
+			if reflect.TypeOf(v.Value).Kind().String() == "string" && !cmd.flags.isPath(k) {
 				if len(v.Value.(string)) > 0 {
 					splitArgs, _ := shlex.Split(v.Value.(string), true, false)
 					flags[k] = &FlagMapItem{
 						Value:     splitArgs[0],
-						IsDefault: false,
+						IsDefault: v.IsDefault,
+						Source:    v.Source,
 					}
 				}
 			}
 		}
 	}
 	// Parse the arguments.
+	// Flags and args not passed on the command line take the global values.
+	var fallback func(item *argItem) (string, bool)
+	if !cmd.isBuiltin {
+		a.applyGlobalFlags(cmd, flags)
+		fallback = a.globalArg
+	}
+	err = cmd.flags.validate(flags)
+	if err != nil {
+		return nil, err
+	}
 	cmdArgMap := make(ArgMap)
-	args, err = cmd.args.parse(args, cmdArgMap)
+	args, err = cmd.args.parse(args, cmdArgMap, fallback)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Check, if values from the argument string are not consumed (and therefore invalid).
 	if len(args) > 0 {
-		return fmt.Errorf("invalid usage of command '%s' (unconsumed input '%s'), try 'help'", cmd.Name, strings.Join(args, " "))
+		return nil, fmt.Errorf("invalid usage of command '%s' (unconsumed input '%s'), try 'help'", cmd.Name, strings.Join(args, " "))
 	}
 
 	// Create the context and pass the rest args.
@@ -359,13 +510,7 @@ func (a *App) RunCommand(args []string) error {
 			a.Println(t.Render())
 		}
 	}
-	// Run the command.
-	err = cmd.Run(ctx)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return ctx, nil
 }
 
 // Run the application and parse the command line arguments.
@@ -387,6 +532,11 @@ func (a *App) Run() (err error) {
 	//	a.isShell = true
 	//	args = args[1:]
 	//}
+	// Read the config file before the flags take their values from it.
+	err = a.loadConfigFile()
+	if err != nil {
+		return err
+	}
 	// Parse the app command line flags.
 	args, err = a.flags.parse(args, a.flagMap)
 	if err != nil {
@@ -399,8 +549,18 @@ func (a *App) Run() (err error) {
 	//a.isShell = len(args) == 0
 	// JC 220520 获取-i flag值
 	a.isShell = a.flagMap.Bool("interactive")
+	// 执行资源文件时进入交互模式
+	resourceFile := a.flagMap.String("resource")
+	if len(resourceFile) > 0 {
+		a.isShell = true
+	}
 	// JC 240521 获取--debug flag值
 	a.debug = a.flagMap.Bool("debug")
+	err = a.SetOutput(a.flagMap.String("output"))
+	if err != nil {
+		return err
+	}
+	a.query = a.flagMap.String("query")
 	// JC 220520 再根据是否有别的参数，来设置是否为shell模式
 	//if len(args) > 0 {
 	//	a.isShell = false
@@ -436,6 +596,40 @@ func (a *App) Run() (err error) {
 		a.AddCommand(core_unsetf(a))
 		// 添加unseta命令
 		a.AddCommand(core_unseta(a))
+		// 添加save命令
+		a.AddCommand(core_save(a))
+		// 添加load命令
+		a.AddCommand(core_load(a))
+		// 添加setg命令
+		a.AddCommand(core_setg(a))
+		// 添加unsetg命令
+		a.AddCommand(core_unsetg(a))
+		// 添加resource命令
+		a.AddCommand(core_resource(a))
+		// 添加jobs命令
+		a.AddCommand(core_jobs(a))
+		// 添加let命令
+		a.AddCommand(core_let(a))
+		// 添加unset命令
+		a.AddCommand(core_unset(a))
+		// 添加vars命令
+		a.AddCommand(core_vars(a))
+		// 添加alias命令
+		a.AddCommand(core_alias(a))
+		// 添加unalias命令
+		a.AddCommand(core_unalias(a))
+		// 添加history命令
+		a.AddCommand(core_history(a))
+		// 添加spool命令
+		a.AddCommand(core_spool(a))
+		// 添加pwd命令
+		a.AddCommand(core_pwd(a))
+		// 添加set命令
+		a.AddCommand(core_set(a))
+		// 添加results命令
+		a.AddCommand(core_results(a))
+		// 添加last命令
+		a.AddCommand(core_last(a))
 	}
 	// Run the init hook.
 	if a.initHook != nil {
@@ -444,6 +638,7 @@ func (a *App) Run() (err error) {
 			return err
 		}
 	}
+	a.bindConfigFile()
 
 	// Check if a command chould be executed in non-interactive mode.
 	if !a.isShell {
@@ -452,7 +647,20 @@ func (a *App) Run() (err error) {
 			a.printHelp(a, false)
 			return nil
 		}
-		return a.RunCommand(args)
+		// Run the commands chained by ';', '&&' and '||'.
+		groups, ops, err := splitChainArgs(args)
+		if err != nil {
+			return err
+		}
+		steps := make([]chainStep, len(groups))
+		for i := range groups {
+			group := groups[i]
+			steps[i].run = func() error { return a.RunCommand(group) }
+			if i > 0 {
+				steps[i].op = ops[i-1]
+			}
+		}
+		return a.execChain(steps)
 	}
 
 	// Create the readline instance.
@@ -460,9 +668,8 @@ func (a *App) Run() (err error) {
 		Prompt:                 a.currentPrompt,
 		HistorySearchFold:      true, // enable case-insensitive history searching
 		DisableAutoSaveHistory: true,
-		HistoryFile:            a.config.HistoryFile,
 		HistoryLimit:           a.config.HistoryLimit,
-		AutoComplete:           newCompleter(&a.commands, nil),
+		AutoComplete:           newCompleter(a, &a.commands, nil),
 		VimMode:                a.config.VimMode,
 	})
 
@@ -470,6 +677,41 @@ func (a *App) Run() (err error) {
 		return err
 	}
 	a.OnClose(a.rl.Close)
+	a.OnClose(a.killJobs)
+	a.OnClose(a.spool.stop)
+
+	// Capture the whole session, if configured.
+	if len(a.config.SpoolFile) > 0 {
+		err = a.spool.start(a.config.SpoolFile, true)
+		if err != nil {
+			a.PrintError(err)
+		}
+	}
+
+	// The history file is handled by the app to store timestamps.
+	err = a.loadHistory()
+	if err != nil {
+		a.PrintError(err)
+	}
+	err = a.loadResults()
+	if err != nil {
+		a.PrintError(err)
+	}
+
+	// Restore the option state and save it again on exit.
+	err = a.autoLoadState()
+	if err != nil {
+		a.PrintError(err)
+	}
+	if len(a.config.StateFile) > 0 {
+		a.OnClose(func() error {
+			return a.saveState(a.config.StateFile)
+		})
+	}
+	err = a.autoLoadAliases()
+	if err != nil {
+		a.PrintError(err)
+	}
 
 	// Run the shell hook.
 	if a.shellHook != nil {
@@ -484,6 +726,22 @@ func (a *App) Run() (err error) {
 		a.printASCIILogo(a)
 	}
 
+	// Execute the startup rc file and the resource file passed by flag.
+	if len(a.config.RCFile) > 0 && a.config.RCFile != "-" {
+		if _, err := os.Stat(a.config.RCFile); err == nil {
+			err = a.RunResource(a.config.RCFile, false)
+			if err != nil {
+				a.PrintError(err)
+			}
+		}
+	}
+	if len(resourceFile) > 0 {
+		err = a.RunResource(resourceFile, false)
+		if err != nil {
+			a.PrintError(err)
+		}
+	}
+
 	// Run the shell.
 	return a.runShell()
 }
@@ -539,33 +797,69 @@ Loop:
 			continue Loop
 		}
 
-		// Save command history.
-		err = a.rl.SaveHistory(line)
+		// Record the typed line in the transcript.
+		fmt.Fprintf(a.spool, "%s%s\n", a.currentPrompt, line)
+
+		// Expand the history references like !! and !n.
+		line, expanded, err := a.expandHistory(line)
 		if err != nil {
 			a.PrintError(err)
 			continue Loop
+		} else if expanded {
+			a.Println(line)
 		}
 
-		// Split the line to args.
-		args, err := shlex.Split(line, true, true)
-		//jlog.Error("line:",line,"args:",len(args),args)
+		// Save command history.
+		err = a.addHistory(line)
 		if err != nil {
-			a.PrintError(fmt.Errorf("invalid args: %v", err))
-			continue Loop
+			a.PrintError(err)
 		}
+
 		// Execute the command.
-		err = a.RunCommand(args)
-		if err != nil {
+		err = a.execLine(line)
+		if errors.Is(err, ErrInterruptExit) {
+			return err
+		} else if err != nil {
 			a.PrintError(err)
-			// Do not continue the Loop here. We want to handle command changes below.
-			//continue Loop
-		}
-		// Sort the commands again if they have changed (Add or remove action).
-		if a.commands.hasChanged() {
-			a.commands.SortRecursive()
-			a.commands.unsetChanged()
 		}
 	}
 
 	return nil
 }
+
+// execLine executes a single shell line.
+func (a *App) execLine(line string) error {
+	return a.execLineIO(line, nil, nil)
+}
+
+// execLineIO executes a single shell line with the input and output of a pipe,
+// e.g. the body of an alias. Nil values keep the current input and output.
+func (a *App) execLineIO(line string, stdin io.Reader, stdout io.Writer) error {
+	parts, ops, err := splitChainLine(line)
+	if err != nil {
+		return err
+	}
+
+	// Split the line to args and execute the commands.
+	steps := make([]chainStep, len(parts))
+	for i := range parts {
+		part := parts[i]
+		steps[i].run = func() error {
+			err := a.execPipeline(part, stdin, stdout)
+			a.lastErr = err
+			return err
+		}
+		if i > 0 {
+			steps[i].op = ops[i-1]
+		}
+	}
+	err = a.execChain(steps)
+	// Do not return here. We want to handle command changes below.
+
+	// Sort the commands again if they have changed (Add or remove action).
+	if a.commands.hasChanged() {
+		a.commands.SortRecursive()
+		a.commands.unsetChanged()
+	}
+	return err
+}
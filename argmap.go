@@ -26,6 +26,8 @@ package jishell
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"time"
 )
 
@@ -33,11 +35,24 @@ import (
 type ArgMapItem struct {
 	Value     interface{}
 	IsDefault bool
+
+	// file is the path of the file a list has been read from.
+	file string
 }
 
 // ArgMap holds all the parsed arg values.
 type ArgMap map[string]*ArgMapItem
 
+// clone returns a copy of the arg map, which can be used independently.
+func (a ArgMap) clone() ArgMap {
+	m := make(ArgMap, len(a))
+	for k, v := range a {
+		item := *v
+		m[k] = &item
+	}
+	return m
+}
+
 // String returns the given arg value as string.
 // Panics if not present. Args must be registered.
 func (a ArgMap) String(name string) string {
@@ -286,3 +301,143 @@ func (a ArgMap) DurationList(long string) []time.Duration {
 	}
 	return v
 }
+
+// IP returns the given argument value as IP address.
+// Panics if not present. Args must be registered.
+func (a ArgMap) IP(name string) net.IP {
+	i := a[name]
+	if i == nil {
+		panic(fmt.Errorf("missing argument value: arg '%s' not registered", name))
+	}
+	v, ok := i.Value.(net.IP)
+	if !ok {
+		panic(fmt.Errorf("failed to assert argument '%s' to IP address", name))
+	}
+	return v
+}
+
+// IPList returns the given argument value as IP address list.
+// Panics if not present. Args must be registered.
+func (a ArgMap) IPList(name string) []net.IP {
+	i := a[name]
+	if i == nil {
+		panic(fmt.Errorf("missing argument value: arg '%s' not registered", name))
+	}
+	v, ok := i.Value.([]net.IP)
+	if !ok {
+		panic(fmt.Errorf("failed to assert argument '%s' to IP address list", name))
+	}
+	return v
+}
+
+// CIDR returns the given argument value as CIDR network.
+// Panics if not present. Args must be registered.
+func (a ArgMap) CIDR(name string) *net.IPNet {
+	i := a[name]
+	if i == nil {
+		panic(fmt.Errorf("missing argument value: arg '%s' not registered", name))
+	}
+	v, ok := i.Value.(*net.IPNet)
+	if !ok {
+		panic(fmt.Errorf("failed to assert argument '%s' to CIDR network", name))
+	}
+	return v
+}
+
+// CIDRList returns the given argument value as CIDR network list.
+// Panics if not present. Args must be registered.
+func (a ArgMap) CIDRList(name string) []*net.IPNet {
+	i := a[name]
+	if i == nil {
+		panic(fmt.Errorf("missing argument value: arg '%s' not registered", name))
+	}
+	v, ok := i.Value.([]*net.IPNet)
+	if !ok {
+		panic(fmt.Errorf("failed to assert argument '%s' to CIDR network list", name))
+	}
+	return v
+}
+
+// HostPort returns the given argument value as host:port.
+// Panics if not present. Args must be registered.
+func (a ArgMap) HostPort(name string) HostPort {
+	i := a[name]
+	if i == nil {
+		panic(fmt.Errorf("missing argument value: arg '%s' not registered", name))
+	}
+	v, ok := i.Value.(HostPort)
+	if !ok {
+		panic(fmt.Errorf("failed to assert argument '%s' to host:port", name))
+	}
+	return v
+}
+
+// HostPortList returns the given argument value as host:port list.
+// Panics if not present. Args must be registered.
+func (a ArgMap) HostPortList(name string) []HostPort {
+	i := a[name]
+	if i == nil {
+		panic(fmt.Errorf("missing argument value: arg '%s' not registered", name))
+	}
+	v, ok := i.Value.([]HostPort)
+	if !ok {
+		panic(fmt.Errorf("failed to assert argument '%s' to host:port list", name))
+	}
+	return v
+}
+
+// PortRange returns the given argument value as port range.
+// Panics if not present. Args must be registered.
+func (a ArgMap) PortRange(name string) PortRange {
+	i := a[name]
+	if i == nil {
+		panic(fmt.Errorf("missing argument value: arg '%s' not registered", name))
+	}
+	v, ok := i.Value.(PortRange)
+	if !ok {
+		panic(fmt.Errorf("failed to assert argument '%s' to port range", name))
+	}
+	return v
+}
+
+// PortRangeList returns the given argument value as port range list.
+// Panics if not present. Args must be registered.
+func (a ArgMap) PortRangeList(name string) []PortRange {
+	i := a[name]
+	if i == nil {
+		panic(fmt.Errorf("missing argument value: arg '%s' not registered", name))
+	}
+	v, ok := i.Value.([]PortRange)
+	if !ok {
+		panic(fmt.Errorf("failed to assert argument '%s' to port range list", name))
+	}
+	return v
+}
+
+// URL returns the given argument value as URL.
+// Panics if not present. Args must be registered.
+func (a ArgMap) URL(name string) *url.URL {
+	i := a[name]
+	if i == nil {
+		panic(fmt.Errorf("missing argument value: arg '%s' not registered", name))
+	}
+	v, ok := i.Value.(*url.URL)
+	if !ok {
+		panic(fmt.Errorf("failed to assert argument '%s' to URL", name))
+	}
+	return v
+}
+
+// URLList returns the given argument value as URL list.
+// Panics if not present. Args must be registered.
+func (a ArgMap) URLList(name string) []*url.URL {
+	i := a[name]
+	if i == nil {
+		panic(fmt.Errorf("missing argument value: arg '%s' not registered", name))
+	}
+	v, ok := i.Value.([]*url.URL)
+	if !ok {
+		panic(fmt.Errorf("failed to assert argument '%s' to URL list", name))
+	}
+	return v
+}
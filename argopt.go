@@ -25,7 +25,16 @@
 package jishell
 
 // ArgOption can be supplied to modify an argument.
-type ArgOption func(*argItem)
+type ArgOption interface {
+	applyArg(i *argItem)
+}
+
+// argOptionFunc is an option, which only applies to arguments.
+type argOptionFunc func(*argItem)
+
+func (f argOptionFunc) applyArg(i *argItem) {
+	f(i)
+}
 
 // Min sets the minimum required number of elements for a list argument.
 func Min(m int) ArgOption {
@@ -33,13 +42,13 @@ func Min(m int) ArgOption {
 		panic("min must be >= 0")
 	}
 
-	return func(i *argItem) {
+	return argOptionFunc(func(i *argItem) {
 		if !i.isList {
 			panic("min option only valid for list arguments")
 		}
 
 		i.listMin = m
-	}
+	})
 }
 
 // Max sets the maximum required number of elements for a list argument.
@@ -48,13 +57,13 @@ func Max(m int) ArgOption {
 		panic("max must be >= 1")
 	}
 
-	return func(i *argItem) {
+	return argOptionFunc(func(i *argItem) {
 		if !i.isList {
 			panic("max option only valid for list arguments")
 		}
 
 		i.listMax = m
-	}
+	})
 }
 
 // Default sets a default value for the argument.
@@ -64,8 +73,8 @@ func Default(v interface{}) ArgOption {
 		panic("nil default value not allowed")
 	}
 
-	return func(i *argItem) {
+	return argOptionFunc(func(i *argItem) {
 		i.Default = v
 		i.optional = true
-	}
+	})
 }
@@ -26,7 +26,9 @@ package jishell
 
 import (
 	"fmt"
-	"github.com/chroblert/go-shlex"
+	"net"
+	"net/url"
+	"reflect"
 	"strconv"
 	"time"
 )
@@ -43,6 +45,7 @@ type argItem struct {
 	Default  interface{}
 
 	parser   parseArgFunc
+	rules    valueRules
 	isList   bool
 	optional bool
 	listMin  int
@@ -91,7 +94,7 @@ func (a *Args) register(
 	// Apply options.
 	// Afterwards, we can make some final checks.
 	for _, opt := range opts {
-		opt(item)
+		opt.applyArg(item)
 	}
 
 	if item.isList && item.listMax > 0 && item.listMax < item.listMin {
@@ -122,7 +125,8 @@ func (a *Args) empty() bool {
 }
 
 // 如果运行正常，则len([]string{})应该为0
-func (a *Args) parse(args []string, res ArgMap) ([]string, error) {
+// The optional fallback returns the value for an argument, which has not been passed.
+func (a *Args) parse(args []string, res ArgMap, fallback func(item *argItem) (string, bool)) ([]string, error) {
 	// Iterate over all arguments that have been registered.
 	// There must be either a default value or a value available,
 	// otherwise the argument is missing.
@@ -136,6 +140,17 @@ func (a *Args) parse(args []string, res ArgMap) ([]string, error) {
 
 		// If no arguments are left, simply set the default values.
 		if len(args) == 0 {
+			// Use the fallback value if present.
+			if fallback != nil {
+				if v, ok := fallback(item); ok {
+					_, err = item.parse([]string{v}, res)
+					if err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+
 			// Check, if the argument is mandatory.
 			if !item.optional {
 				return nil, fmt.Errorf("missing argument '%s'", item.Name)
@@ -145,25 +160,7 @@ func (a *Args) parse(args []string, res ArgMap) ([]string, error) {
 			res[item.Name] = &ArgMapItem{Value: item.Default, IsDefault: true}
 			continue
 		}
-		// JC 220514: 调换一下顺序
-		// If it is a list argument, it will consume the rest of the input.
-		// Check that it matches its range.
-		// 用于校验
-		if item.isList {
-			// List以,分隔
-			args0List, err := shlex.Split(args[0], true, false, ',')
-			if err != nil {
-				return nil, err
-			}
-			if len(args0List) < item.listMin {
-				return nil, fmt.Errorf("argument '%s' requires at least %d element(s)", item.Name, item.listMin)
-			}
-			if item.listMax > 0 && len(args0List) > item.listMax {
-				return nil, fmt.Errorf("argument '%s' requires at most %d element(s)", item.Name, item.listMax)
-			}
-		}
-
-		args, err = item.parser(args, res)
+		args, err = item.parse(args, res)
 		if err != nil {
 			return nil, err
 		}
@@ -172,6 +169,33 @@ func (a *Args) parse(args []string, res ArgMap) ([]string, error) {
 	return args, nil
 }
 
+// parse parses the value of the argument and checks it against its rules.
+// The result is only stored, if the value is valid.
+func (item *argItem) parse(args []string, res ArgMap) ([]string, error) {
+	tmp := make(ArgMap, 1)
+	args, err := item.parser(args, tmp)
+	if err != nil {
+		return nil, err
+	}
+	// Check that a list matches its range.
+	// The elements are counted after parsing, since a list may be read from a file.
+	if item.isList {
+		n := reflect.ValueOf(tmp[item.Name].Value).Len()
+		if n < item.listMin {
+			return nil, fmt.Errorf("argument '%s' requires at least %d element(s)", item.Name, item.listMin)
+		}
+		if item.listMax > 0 && n > item.listMax {
+			return nil, fmt.Errorf("argument '%s' requires at most %d element(s)", item.Name, item.listMax)
+		}
+	}
+	err = item.rules.check("argument '"+item.Name+"'", tmp[item.Name].Value)
+	if err != nil {
+		return nil, err
+	}
+	res[item.Name] = tmp[item.Name]
+	return args, nil
+}
+
 // String registers a string argument.
 func (a *Args) String(name, help string, opts ...ArgOption) {
 	a.register(name, help, "string", false,
@@ -194,11 +218,11 @@ func (a *Args) StringList(name, help string, opts ...ArgOption) {
 	a.register(name, help, "string list,separate by comma.eg: ele1,ele2,ele3...", true,
 		func(args []string, res ArgMap) ([]string, error) {
 			//jlog.Error(len(args),args)
-			splitArgs, err := shlex.Split(args[0], true, false, ',')
+			splitArgs, file, err := splitList(args[0], argListFile(opts))
 			if err != nil {
 				return nil, err
 			}
-			res[name] = &ArgMapItem{Value: splitArgs}
+			res[name] = &ArgMapItem{Value: splitArgs, file: file}
 			args = args[1:]
 			return args, nil
 		},
@@ -227,7 +251,7 @@ func (a *Args) BoolList(name, help string, opts ...ArgOption) {
 	a.register(name, help, "bool list", true,
 		func(args []string, res ArgMap) ([]string, error) {
 			//jlog.Error(len(args),args)
-			splitArgs, err2 := shlex.Split(args[0], true, false, ',')
+			splitArgs, file, err2 := splitList(args[0], argListFile(opts))
 			if err2 != nil {
 				return nil, err2
 			}
@@ -242,7 +266,7 @@ func (a *Args) BoolList(name, help string, opts ...ArgOption) {
 				}
 			}
 
-			res[name] = &ArgMapItem{Value: bs}
+			res[name] = &ArgMapItem{Value: bs, file: file}
 			args = args[1:]
 			return args, nil
 		},
@@ -271,7 +295,7 @@ func (a *Args) IntList(name, help string, opts ...ArgOption) {
 	a.register(name, help, "int list", true,
 		func(args []string, res ArgMap) ([]string, error) {
 			//jlog.Error(len(args),args)
-			splitArgs, err2 := shlex.Split(args[0], true, false, ',')
+			splitArgs, file, err2 := splitList(args[0], argListFile(opts))
 			if err2 != nil {
 				return nil, err2
 			}
@@ -286,7 +310,7 @@ func (a *Args) IntList(name, help string, opts ...ArgOption) {
 				}
 			}
 
-			res[name] = &ArgMapItem{Value: is}
+			res[name] = &ArgMapItem{Value: is, file: file}
 			//jlog.Error(res)
 			args = args[1:]
 			return args, nil
@@ -315,18 +339,40 @@ func (a *Args) Int64(name, help string, opts ...ArgOption) {
 func (a *Args) Int64List(name, help string, opts ...ArgOption) {
 	a.register(name, help, "int64 list", true,
 		func(args []string, res ArgMap) ([]string, error) {
+			// The elements are the remaining args, which may be separated by comma
+			// as well, or the lines of the list file.
+			var (
+				elems []string
+				file  string
+			)
+			if path, ok := listFilePath(args[0]); ok && len(args) == 1 && argListFile(opts) {
+				var err error
+				elems, err = readListFile(path)
+				if err != nil {
+					return nil, err
+				}
+				file = path
+			} else {
+				for _, arg := range args {
+					splitArgs, _, err := splitList(arg, false)
+					if err != nil {
+						return nil, err
+					}
+					elems = append(elems, splitArgs...)
+				}
+			}
 			var (
 				err error
-				is  = make([]int64, len(args))
+				is  = make([]int64, len(elems))
 			)
-			for i, a := range args {
+			for i, a := range elems {
 				is[i], err = strconv.ParseInt(a, 10, 64)
 				if err != nil {
 					return nil, fmt.Errorf("invalid int64 value '%s' for argument: %s", a, name)
 				}
 			}
 
-			res[name] = &ArgMapItem{Value: is}
+			res[name] = &ArgMapItem{Value: is, file: file}
 			return []string{}, nil
 		},
 		opts...,
@@ -354,7 +400,7 @@ func (a *Args) UintList(name, help string, opts ...ArgOption) {
 	a.register(name, help, "uint list", true,
 		func(args []string, res ArgMap) ([]string, error) {
 			//jlog.Error(len(args),args)
-			splitArgs, err2 := shlex.Split(args[0], true, false, ',')
+			splitArgs, file, err2 := splitList(args[0], argListFile(opts))
 			if err2 != nil {
 				return nil, err2
 			}
@@ -371,7 +417,7 @@ func (a *Args) UintList(name, help string, opts ...ArgOption) {
 				is[i] = uint(u)
 			}
 
-			res[name] = &ArgMapItem{Value: is}
+			res[name] = &ArgMapItem{Value: is, file: file}
 			args = args[1:]
 			return args, nil
 		},
@@ -400,7 +446,7 @@ func (a *Args) Uint64List(name, help string, opts ...ArgOption) {
 	a.register(name, help, "uint64 list", true,
 		func(args []string, res ArgMap) ([]string, error) {
 			//jlog.Error(len(args),args)
-			splitArgs, err2 := shlex.Split(args[0], true, false, ',')
+			splitArgs, file, err2 := splitList(args[0], argListFile(opts))
 			if err2 != nil {
 				return nil, err2
 			}
@@ -415,7 +461,7 @@ func (a *Args) Uint64List(name, help string, opts ...ArgOption) {
 				}
 			}
 
-			res[name] = &ArgMapItem{Value: us}
+			res[name] = &ArgMapItem{Value: us, file: file}
 			args = args[1:]
 			return args, nil
 		},
@@ -444,7 +490,7 @@ func (a *Args) Float64List(name, help string, opts ...ArgOption) {
 	a.register(name, help, "float64 list", true,
 		func(args []string, res ArgMap) ([]string, error) {
 			//jlog.Error(len(args),args)
-			splitArgs, err2 := shlex.Split(args[0], true, false, ',')
+			splitArgs, file, err2 := splitList(args[0], argListFile(opts))
 			if err2 != nil {
 				return nil, err2
 			}
@@ -459,7 +505,7 @@ func (a *Args) Float64List(name, help string, opts ...ArgOption) {
 				}
 			}
 
-			res[name] = &ArgMapItem{Value: fs}
+			res[name] = &ArgMapItem{Value: fs, file: file}
 			args = args[1:]
 			return args, nil
 		},
@@ -488,7 +534,7 @@ func (a *Args) DurationList(name, help string, opts ...ArgOption) {
 	a.register(name, help, "duration list", true,
 		func(args []string, res ArgMap) ([]string, error) {
 			//jlog.Error(len(args),args)
-			splitArgs, err2 := shlex.Split(args[0], true, false, ',')
+			splitArgs, file, err2 := splitList(args[0], argListFile(opts))
 			if err2 != nil {
 				return nil, err2
 			}
@@ -503,10 +549,114 @@ func (a *Args) DurationList(name, help string, opts ...ArgOption) {
 				}
 			}
 
-			res[name] = &ArgMapItem{Value: ds}
+			res[name] = &ArgMapItem{Value: ds, file: file}
 			args = args[1:]
 			return args, nil
 		},
 		opts...,
 	)
 }
+
+// IP registers an IP address argument.
+func (a *Args) IP(name, help string, opts ...ArgOption) {
+	a.registerValue(name, help, "ip", parseIP, opts...)
+}
+
+// IPList registers a IP address list argument. The elements are separated by comma.
+func (a *Args) IPList(name, help string, opts ...ArgOption) {
+	a.registerValueList(name, help, "ip list", []net.IP(nil), parseIP, opts...)
+}
+
+// CIDR registers a CIDR network argument.
+func (a *Args) CIDR(name, help string, opts ...ArgOption) {
+	a.registerValue(name, help, "cidr", parseCIDR, opts...)
+}
+
+// CIDRList registers a CIDR network list argument. The elements are separated by comma.
+func (a *Args) CIDRList(name, help string, opts ...ArgOption) {
+	a.registerValueList(name, help, "cidr list", []*net.IPNet(nil), parseCIDR, opts...)
+}
+
+// HostPort registers a host:port argument.
+func (a *Args) HostPort(name, help string, opts ...ArgOption) {
+	a.registerValue(name, help, "host:port", parseHostPort, opts...)
+}
+
+// HostPortList registers a host:port list argument. The elements are separated by comma.
+func (a *Args) HostPortList(name, help string, opts ...ArgOption) {
+	a.registerValueList(name, help, "host:port list", []HostPort(nil), parseHostPort, opts...)
+}
+
+// PortRange registers a port range argument.
+func (a *Args) PortRange(name, help string, opts ...ArgOption) {
+	a.registerValue(name, help, "port range", parsePortRange, opts...)
+}
+
+// PortRangeList registers a port range list argument. The elements are separated by comma.
+func (a *Args) PortRangeList(name, help string, opts ...ArgOption) {
+	a.registerValueList(name, help, "port range list", []PortRange(nil), parsePortRange, opts...)
+}
+
+// URL registers an URL argument.
+func (a *Args) URL(name, help string, opts ...ArgOption) {
+	a.registerValue(name, help, "url", parseURL, opts...)
+}
+
+// URLList registers a URL list argument. The elements are separated by comma.
+func (a *Args) URLList(name, help string, opts ...ArgOption) {
+	a.registerValueList(name, help, "url list", []*url.URL(nil), parseURL, opts...)
+}
+
+// Path registers a filesystem path argument, which is completed from the filesystem.
+// A leading ~ is expanded to the home directory.
+func (a *Args) Path(name, help string, opts ...ArgOption) {
+	a.registerValue(name, help, "path", parsePath, append([]ArgOption{pathKind(pathAny)}, opts...)...)
+}
+
+// File registers a file path argument. The path must not be a directory.
+func (a *Args) File(name, help string, opts ...ArgOption) {
+	a.registerValue(name, help, "file", parsePath, append([]ArgOption{pathKind(pathFile)}, opts...)...)
+}
+
+// Dir registers a directory path argument. The path must be a directory
+// and only directories are completed.
+func (a *Args) Dir(name, help string, opts ...ArgOption) {
+	a.registerValue(name, help, "dir", parsePath, append([]ArgOption{pathKind(pathDir)}, opts...)...)
+}
+
+// registerValue registers an argument, whose value is parsed by parse.
+func (a *Args) registerValue(name, help, helpArgs string, parse func(s string) (interface{}, error), opts ...ArgOption) {
+	a.register(name, help, helpArgs, false,
+		func(args []string, res ArgMap) ([]string, error) {
+			v, err := parse(args[0])
+			if err != nil {
+				return nil, fmt.Errorf("%v for argument: %s", err, name)
+			}
+
+			res[name] = &ArgMapItem{Value: v}
+			return args[1:], nil
+		},
+		opts...,
+	)
+}
+
+// registerValueList registers a list argument, whose elements are parsed by parse
+// and stored in a slice of the same type as list.
+func (a *Args) registerValueList(name, help, helpArgs string, list interface{}, parse func(s string) (interface{}, error), opts ...ArgOption) {
+	a.register(name, help, helpArgs, true,
+		func(args []string, res ArgMap) ([]string, error) {
+			splitArgs, file, err := splitList(args[0], argListFile(opts))
+			if err != nil {
+				return nil, err
+			}
+			vs, err := parseValueList(list, splitArgs, parse)
+			if err != nil {
+				return nil, fmt.Errorf("%v for argument: %s", err, name)
+			}
+
+			res[name] = &ArgMapItem{Value: vs, file: file}
+			return args[1:], nil
+		},
+		opts...,
+	)
+}
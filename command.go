@@ -25,10 +25,31 @@
 package jishell
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// InterruptPolicy defines how a running command handles Ctrl-C.
+type InterruptPolicy int
+
+const (
+	// InterruptCancel cancels the context of the command. This is the default.
+	// The shell waits for the command to return, also on repeated Ctrl-C,
+	// so commands must stop once their context is done. The interrupt handler
+	// of the app is only called at the prompt.
+	InterruptCancel InterruptPolicy = iota
+	// InterruptIgnore ignores Ctrl-C while the command is running.
+	InterruptIgnore
+	// InterruptExit cancels the context of the command and exits the application,
+	// once the command has returned. The shell returns ErrInterruptExit.
+	InterruptExit
+)
+
+// ErrInterruptExit is returned, if a command with the InterruptExit policy
+// has been interrupted. The app is closed regularly.
+var ErrInterruptExit = errors.New("interrupted")
+
 // Command is just that, a command for your application.
 type Command struct {
 	// Command name.
@@ -59,9 +80,17 @@ type Command struct {
 	// Define all command arguments within this function.
 	Args func(a *Args)
 
+	// Options defines flags and args by the tags of the fields of this struct,
+	// e.g. `flag:"t,target" default:"x" help:"..."` or `arg:"name"`.
+	// Use Context.Bind to obtain the values in the run function.
+	Options interface{}
+
 	// Function to execute for the command.
 	Run func(c *Context) error
 
+	// Interrupt defines how the command handles Ctrl-C while it is running.
+	Interrupt InterruptPolicy
+
 	// Completer is custom autocompleter for command.
 	// It takes in command arguments and returns autocomplete options.
 	// By default all commands get autocomplete of subcommands.
@@ -75,9 +104,10 @@ type Command struct {
 	isBuiltin bool    // Whenever this is a build-in command not added by the user.
 	jflagMaps FlagMap // JC0o0l add
 	//CMDPath     string   // JC0o0l add.用来指定命令所在路径，模拟用。可以用来自动补全
-	jargMaps    ArgMap   // JC 220512 add
-	parentPath  string   // JC 220520 记录从app至父命令的路径
-	previousCmd *Command // JC 220521 存放use切换前的Command，初始为nil
+	jargMaps    ArgMap          // JC 220512 add
+	parentPath  string          // JC 220520 记录从app至父命令的路径
+	previousCmd *Command        // JC 220521 存放use切换前的Command，初始为nil
+	globalOpts  map[string]bool // flags and args, which took the value set by setg
 }
 
 func (c *Command) validate() error {
@@ -99,6 +129,9 @@ func (c *Command) registerFlagsAndArgs(addHelpFlag bool) {
 		c.flags.Bool("h", "help", false, "display help")
 	}
 
+	if c.Options != nil {
+		registerOptions(&c.flags, &c.args, c.Options)
+	}
 	if c.Flags != nil {
 		c.Flags(&c.flags)
 	}
@@ -145,6 +178,80 @@ func (c *Command) AddCommand(cmd *Command) {
 	c.commands.Add(cmd)
 }
 
+// fullPath returns the absolute path of the command, e.g. /s1/s2.
+func (c *Command) fullPath() string {
+	var names []string
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		names = append([]string{cmd.Name}, names...)
+	}
+	return "/" + strings.Join(names, "/")
+}
+
+// initOptions initializes the flag and arg maps used by use, setf, seta and run.
+func (c *Command) initOptions() error {
+	if c.jflagMaps == nil {
+		c.jflagMaps = make(FlagMap)
+		// flag会使用默认值进行初始化，(arg只有list有默认空值，不能用这种方法)
+		_, err := c.flags.parse([]string{}, c.jflagMaps)
+		if err != nil {
+			return err
+		}
+	}
+	if c.jargMaps == nil {
+		c.jargMaps = make(ArgMap)
+	}
+	return nil
+}
+
+// setFlag parses the value with the parser of the flag and stores it
+// in the option state of the command.
+func (c *Command) setFlag(name, value string) error {
+	err := c.initOptions()
+	if err != nil {
+		return err
+	}
+	for _, v := range c.flags.list {
+		if v.Long != name {
+			continue
+		}
+		// Parse into a copy to keep the previous value on invalid input.
+		res := c.jflagMaps.clone()
+		_, err = c.flags.parse(flagArgs(name, value), res)
+		if err != nil {
+			return err
+		}
+		res[name].Source = SourceSet
+		c.jflagMaps[name] = res[name]
+		return nil
+	}
+	return fmt.Errorf("invalid flag: %s", name)
+}
+
+// flagArgs returns the command line arguments to pass the value to the flag.
+func flagArgs(long, value string) []string {
+	if len(value) == 0 {
+		return []string{"--" + long, value}
+	}
+	return []string{"--" + long + "=" + value}
+}
+
+// setArg parses the value with the parser of the arg and stores it
+// in the option state of the command.
+func (c *Command) setArg(name, value string) error {
+	err := c.initOptions()
+	if err != nil {
+		return err
+	}
+	for _, v := range c.args.list {
+		if v.Name != name {
+			continue
+		}
+		_, err = v.parse([]string{value}, c.jargMaps)
+		return err
+	}
+	return fmt.Errorf("invalid arg: %s", name)
+}
+
 func (c *Command) SetParam(param string, paramValue string) error {
 	// 如何设置flagMapItem
 	return nil
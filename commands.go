@@ -98,6 +98,38 @@ func (c *Commands) Get(name string) *Command {
 	return nil
 }
 
+// getPath returns the command registered at the absolute path, e.g. /s1/s2.
+// Returns nil if not found.
+func (c *Commands) getPath(path string) *Command {
+	var cmd *Command
+	cur := c
+	for _, name := range strings.Split(path, "/") {
+		if len(name) == 0 {
+			continue
+		}
+		cmd = nil
+		for _, v := range cur.list {
+			if v.Name == name {
+				cmd = v
+				break
+			}
+		}
+		if cmd == nil {
+			return nil
+		}
+		cur = &cmd.commands
+	}
+	return cmd
+}
+
+// walk calls fn for every command including all sub commands.
+func (c *Commands) walk(fn func(cmd *Command)) {
+	for _, cmd := range c.list {
+		fn(cmd)
+		cmd.commands.walk(fn)
+	}
+}
+
 // FindCommand searches for the final command through all children.
 // Returns a slice of non processed following command args.
 // Returns cmd=nil if not found.
@@ -31,13 +31,15 @@ import (
 )
 
 type completer struct {
+	app        *App
 	commands   *Commands
 	currentCmd *Command
 }
 
-func newCompleter(commands *Commands, currentCmd *Command) *completer {
+func newCompleter(app *App, commands *Commands, currentCmd *Command) *completer {
 	//jlog.Error("new completer")
 	return &completer{
+		app:        app,
 		commands:   commands,
 		currentCmd: currentCmd,
 	}
@@ -49,10 +51,16 @@ func (c *completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 	//jlog.Error(string(line),pos)
 	line = line[:pos]
 
-	var words []string
+	var (
+		words []string
+		quote string // 未闭合的引号
+	)
 	// 以空白字符进行分隔，若无报错，则words值为空白字符分隔的字符串列表
 	if w, err := shlex.Split(string(line), true, false); err == nil {
 		words = w
+	} else if w, q, ok := splitOpenQuote(string(line)); ok {
+		// 最后一个字符串的引号未闭合，如: cmd --file 'my fi
+		words, quote = w, q
 	} else {
 		//jlog.Error("error:", err)
 		words = strings.Fields(string(line)) // fallback
@@ -64,7 +72,7 @@ func (c *completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 	// words为其余的字符串列表
 	// 若最后一个字符为空格，则prefix为空字符串；words为字符串列表
 	//jlog.Warn("-:", len(words), words, ",line:", fmt.Sprintf("_%s_", string(line)))
-	if len(words) > 0 && pos >= 1 && line[pos-1] != ' ' {
+	if len(words) > 0 && pos >= 1 && (line[pos-1] != ' ' || len(quote) > 0) {
 		prefix = words[len(words)-1]
 		words = words[:len(words)-1]
 	}
@@ -77,6 +85,7 @@ func (c *completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 		flags             *Flags
 		args              *Args
 		suggestions       [][]rune
+		values            []string // 别名或可选值
 		firstIsBuiltInCmd bool
 	)
 	firstIsBuiltInCmd = true
@@ -87,6 +96,7 @@ func (c *completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 				cmds = append(cmds, v)
 			}
 		}
+		values = c.app.sortedAliasNames()
 	} else {
 		switch words[0] {
 		case "help":
@@ -97,30 +107,59 @@ func (c *completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 				}
 			}
 		case "use":
-			for _, v := range c.commands.list {
-				//jlog.Info("cmd:", v.Name, v.isBuiltin)
-				if !v.isBuiltin {
-					cmds = append(cmds, v)
-				}
+			// 补全命令路径
+			if len(words) == 1 {
+				return c.app.completeCommandPath(prefix)
 			}
+			return
 		case "seta":
-			if c.currentCmd != nil {
+			if c.currentCmd == nil {
+				break
+			}
+			if len(words) == 1 {
 				args = &c.currentCmd.args
+			} else if len(words) == 2 {
+				// 补全arg的值
+				for _, a := range c.currentCmd.args.list {
+					if a.Name == words[1] {
+						values = c.valueCompletions(c.currentCmd, &a.rules, a.isList, prefix)
+					}
+				}
 			}
 		case "setf":
-			if c.currentCmd != nil {
+			if c.currentCmd == nil {
+				break
+			}
+			if len(words) == 1 {
 				flags = &c.currentCmd.flags
+			} else if len(words) == 2 {
+				// 补全flag的值
+				values, _ = c.flagValues(c.currentCmd, "--"+words[1], prefix)
 			}
 		case "unseta":
-			if c.currentCmd != nil {
+			if c.currentCmd != nil && len(words) == 1 {
 				args = &c.currentCmd.args
 			}
 		case "unsetf":
-			if c.currentCmd != nil {
+			if c.currentCmd != nil && len(words) == 1 {
 				flags = &c.currentCmd.flags
 			}
+		case "alias", "unalias":
+			if len(words) == 1 {
+				values = c.app.sortedAliasNames()
+			}
 		default: // 非内置命令
 			firstIsBuiltInCmd = false
+			// 补全flag的值，如: cmd --proto <tab>
+			if last := words[len(words)-1]; len(words) > 1 && strings.HasPrefix(last, "-") && !strings.Contains(last, "=") {
+				cmd, _, err := c.commands.FindCommand(words[:len(words)-1])
+				if err == nil && cmd != nil {
+					if completions, ok := c.flagValues(cmd, last, prefix); ok {
+						values = completions
+						break
+					}
+				}
+			}
 			// 子命令 xxx形式
 			// 目的在于找到最后一个命令
 			cmd, rest, err := c.commands.FindCommand(words)
@@ -135,9 +174,11 @@ func (c *completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 				}
 				return suggestions, len(prefix)
 			}
+			// 补全arg的值
+			values = c.argValues(cmd, rest, prefix)
 			// No rest must be there.
 			if len(rest) != 0 {
-				return
+				break
 			}
 			cmds = cmd.commands.list
 			flags = &cmd.flags
@@ -179,6 +220,13 @@ func (c *completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 			}
 		}
 
+		// 自动补全别名和可选值
+		for _, a := range values {
+			if strings.HasPrefix(a, prefix) {
+				suggestions = append(suggestions, []rune(escapeCompletion(strings.TrimPrefix(a, prefix), quote)))
+			}
+		}
+
 		// 自动补全flag，默认显示long flag
 		if flags != nil {
 			// 第一个字符串是内置命令，则只使用长模式
@@ -222,6 +270,9 @@ func (c *completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 				suggestions = append(suggestions, []rune(cmd.Name))
 			}
 		}
+		for _, a := range values {
+			suggestions = append(suggestions, []rune(escapeCompletion(a, quote)))
+		}
 
 		if flags != nil {
 			if firstIsBuiltInCmd {
@@ -253,3 +304,87 @@ func (c *completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 	}
 	return suggestions, len(prefix)
 }
+
+// flagValues returns the completions of the value of the flag, e.g. --proto or -p.
+// It reports, whether the flag takes a value.
+func (c *completer) flagValues(cmd *Command, flag, prefix string) ([]string, bool) {
+	f := findFlag(&cmd.flags, flag)
+	if f == nil || f.HelpArgs == "bool" {
+		return nil, false
+	}
+	return c.valueCompletions(cmd, &f.rules, isList(f.Default), prefix), true
+}
+
+// argValues returns the completions of the arg at the position after the words.
+// Flags and their values are skipped.
+func (c *completer) argValues(cmd *Command, rest []string, prefix string) []string {
+	pos := 0
+	for i := 0; i < len(rest); i++ {
+		w := rest[i]
+		if len(w) < 2 || w[0] != '-' {
+			pos++
+			continue
+		}
+		if f := findFlag(&cmd.flags, w); f != nil && f.HelpArgs != "bool" && !strings.Contains(w, "=") {
+			i++
+		}
+	}
+	if pos >= len(cmd.args.list) {
+		return nil
+	}
+	a := cmd.args.list[pos]
+	return c.valueCompletions(cmd, &a.rules, a.isList, prefix)
+}
+
+// valueCompletions returns the choices, the static completions and the results of
+// the complete functions of a flag or an arg. For lists, the last element is completed.
+func (c *completer) valueCompletions(cmd *Command, r *valueRules, list bool, prefix string) []string {
+	head, elem := "", prefix
+	if i := strings.LastIndexByte(prefix, ','); list && i >= 0 {
+		head, elem = prefix[:i+1], prefix[i+1:]
+	}
+	var values []string
+	values = append(values, r.choices...)
+	values = append(values, r.completions...)
+	if len(r.completeFuncs) > 0 {
+		// The option state is not initialized, so that completing does not
+		// mark the command as used for show and save.
+		flags := cmd.jflagMaps
+		if flags == nil {
+			flags = make(FlagMap)
+			_, _ = cmd.flags.parse([]string{}, flags)
+		}
+		ctx := newContext(c.app, cmd, flags, cmd.runArgs())
+		for _, fn := range r.completeFuncs {
+			values = append(values, fn(ctx, elem)...)
+		}
+	}
+	if r.pathKind != 0 {
+		values = append(values, completePath(elem, r.pathKind == pathDir)...)
+	}
+	for i := range values {
+		values[i] = head + values[i]
+	}
+	return values
+}
+
+// splitOpenQuote splits the line, whose last word has an unclosed quote.
+// It returns the words and the quote.
+func splitOpenQuote(line string) ([]string, string, bool) {
+	for _, q := range []string{"'", `"`} {
+		if w, err := shlex.Split(line+q, true, false); err == nil {
+			return w, q, true
+		}
+	}
+	return nil, "", false
+}
+
+// findFlag returns the flag, e.g. --proto or -p.
+func findFlag(flags *Flags, flag string) *flagItem {
+	for _, f := range flags.list {
+		if flags.match(flag, f.Short, f.Long) {
+			return f
+		}
+	}
+	return nil
+}
@@ -26,6 +26,8 @@ package jishell
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/fatih/color"
 )
@@ -46,11 +48,41 @@ type Config struct {
 	Flags func(f *Flags)
 
 	// Persist readline historys to file if specified.
+	// Each line is preceded by its unix timestamp as comment line, e.g. '#1650000000'.
 	HistoryFile string
 
 	// Specify the max length of historys, it's 500 by default, set it to -1 to disable history.
 	HistoryLimit int
 
+	// Execute the lines of this resource file before the first prompt.
+	// It's ~/.<Name>rc by default and skipped if it does not exist, set it to "-" to disable.
+	RCFile string
+
+	// Persist the flag and arg values of all commands to this file on exit
+	// and restore them on startup if specified. Only used in shell mode.
+	StateFile string
+
+	// Persist the aliases defined by the alias command to this file
+	// and restore them on startup if specified. Only used in shell mode.
+	AliasFile string
+
+	// Write a transcript of all typed lines and the terminal output to this
+	// file if specified. The file is appended. Only used in shell mode.
+	SpoolFile string
+
+	// Persist the results of the commands to this file as JSON lines
+	// and restore them on startup if specified. Only used in shell mode.
+	ResultFile string
+
+	// Specify the max number of kept results, it's 100 by default, set it to -1 to disable them.
+	ResultLimit int
+
+	// Take the flag values from this config file (YAML, JSON or TOML) if specified.
+	// The keys are the command path and the long flag name, e.g. 'cdn.check.timeout',
+	// the flags of the app are top level keys. Values passed on the command line,
+	// set by setf or setg and environment variables take precedence.
+	ConfigFile string
+
 	// NoColor defines if color output should be disabled.
 	NoColor bool
 
@@ -85,6 +117,14 @@ func (c *Config) SetDefaults() {
 	if c.HistoryLimit == 0 {
 		c.HistoryLimit = 500
 	}
+	if c.ResultLimit == 0 {
+		c.ResultLimit = 100
+	}
+	if len(c.RCFile) == 0 {
+		if home, err := os.UserHomeDir(); err == nil {
+			c.RCFile = filepath.Join(home, "."+c.Name+"rc")
+		}
+	}
 	if c.PromptColor == nil {
 		c.PromptColor = color.New(color.FgYellow, color.Bold)
 	}
@@ -24,6 +24,14 @@
 
 package jishell
 
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
 // Context defines a command context.
 type Context struct {
 	// Reference to the app.
@@ -37,6 +45,10 @@ type Context struct {
 
 	// Cmd is the currently executing command.
 	Command *Command
+
+	ctx    context.Context
+	stdin  io.Reader // Output of the previous command in a pipe.
+	stdout io.Writer // Output of the command, the app output if nil.
 }
 
 func newContext(a *App, cmd *Command, flags FlagMap, args ArgMap) *Context {
@@ -45,7 +57,64 @@ func newContext(a *App, cmd *Command, flags FlagMap, args ArgMap) *Context {
 		Command: cmd,
 		Flags:   flags,
 		Args:    args,
+		ctx:     context.Background(),
+	}
+}
+
+// Ctx returns the context.Context of the command. It is canceled if the
+// command is interrupted by Ctrl-C or if its background job has been killed.
+func (c *Context) Ctx() context.Context {
+	return c.ctx
+}
+
+// Done returns a channel that is closed when the command should stop.
+// Shorthand for Ctx().Done().
+func (c *Context) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+// Err returns a non-nil error if the command has been canceled.
+// Shorthand for Ctx().Err().
+func (c *Context) Err() error {
+	return c.ctx.Err()
+}
+
+// Stdin returns the output of the previous command in a pipe.
+// Outside of a pipe, it's empty in the shell and os.Stdin otherwise.
+func (c *Context) Stdin() io.Reader {
+	if c.stdin != nil {
+		return c.stdin
 	}
+	if c.App.isShell {
+		return strings.NewReader("")
+	}
+	return os.Stdin
+}
+
+// Stdout returns the writer for the command output.
+// It's redirected in pipes and to files. Background jobs write to their
+// output buffer instead of the terminal. Without an own output, the command
+// writes to the output of the app, e.g. the redirect target of an alias.
+func (c *Context) Stdout() io.Writer {
+	if c.stdout != nil {
+		return c.stdout
+	}
+	return c.App.Stdout()
+}
+
+// Print writes to the command output.
+func (c *Context) Print(args ...interface{}) (int, error) {
+	return fmt.Fprint(c.Stdout(), args...)
+}
+
+// Printf formats according to a format specifier and writes to the command output.
+func (c *Context) Printf(format string, args ...interface{}) (int, error) {
+	return fmt.Fprintf(c.Stdout(), format, args...)
+}
+
+// Println writes to the command output followed by a newline.
+func (c *Context) Println(args ...interface{}) (int, error) {
+	return fmt.Fprintln(c.Stdout(), args...)
 }
 
 // Stop signalizes the app to exit.
@@ -1,13 +1,16 @@
 package jishell
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/chroblert/go-shlex"
 	"github.com/chroblert/jishell/jconfig"
 	"github.com/desertbit/readline"
 	"github.com/jedib0t/go-pretty/v6/table"
-	"reflect"
+	"github.com/tidwall/gjson"
+	"io/ioutil"
 	"strings"
+	"time"
 )
 
 func core_help(a *App) *Command {
@@ -29,6 +32,11 @@ func core_help(a *App) *Command {
 				}
 				return nil
 			}
+			// Aliases take precedence over commands.
+			if body, ok := c.App.alias(args[0]); ok && len(args) == 1 {
+				c.Printf("%s is an alias for: %s\n", args[0], body)
+				return nil
+			}
 			var cmd *Command
 			var err error
 			if c.App.currentCmd == nil {
@@ -85,68 +93,25 @@ func core_use(a *App) *Command {
 		Help:      "switch command",
 		LongHelp:  "",
 		HelpGroup: jconfig.CORE_COMMAND_STR,
-		Usage:     "use <command|Alias|CMDPath>",
+		Usage:     "use <command|Alias|CMDPath|..|/|->",
 		//Flags:     nil,
 		Args: func(a *Args) {
-			a.String("commandName", "command name")
+			a.String("commandName", "command name or path, e.g. s1/s2, ../s3 or /s1. '..' is the parent, '/' the root and '-' the previous command")
 		},
 		Run: func(c *Context) error {
-			// 获取要切换的command
+			// 获取要切换的command，支持相对路径和绝对路径
 			inputCmdStr := c.Args.String("commandName")
-			//tmpStrSlice := strings.Split(c.Args.String("commandName"),"/")
-			//commandName := tmpStrSlice[len(tmpStrSlice)-1]
-			//commandCategory := tmpStrSlice[0]
-			var tmpCommand = &Command{}
-			// JC 220520 判断是否处在app本身
-			if c.App.currentCmd == nil {
-				tmpCommand = c.App.Commands().Get(inputCmdStr)
-			} else {
-				// 否则取回当前命令的子命令
-				tmpCommand = c.App.currentCmd.commands.Get(inputCmdStr)
-			}
-			if tmpCommand == nil {
-				//jlog.Errorf("error: command u input not exist\n")
-				return fmt.Errorf("command %s u input not exist", inputCmdStr)
-			}
-			//jlog.Error("currentCmd:", c.App.currentCmd)
-			//jlog.Error(tmpCommand)
-			//c.App.previousCmd = c.App.currentCmd // 记录切换前的command
-			tmpCommand.previousCmd = c.App.currentCmd // 记录切换前的command
-			c.App.currentCmd = tmpCommand
-			// 获取命令
-			//jlog.Warn(c.App.currentCommandStr)
-			//jlog.Warn(tmpCommand.Name)
-			//c.App.currentCommandStr = tmpCommand.Name
-			// 设置prompt
-			c.App.currentPrompt = c.App.config.Name + " " + tmpCommand.Name + "(" + tmpCommand.parentPath + ") >> "
-			c.App.SetPrompt(c.App.currentPrompt)
-			// 初始化jflagMaps
-			if tmpCommand.jflagMaps == nil {
-				tmpCommand.jflagMaps = make(FlagMap)
-				// flag会使用默认值进行初始化，(arg只有list有默认空值，不能用这种方法)
-				_, err := tmpCommand.flags.parse([]string{}, tmpCommand.jflagMaps)
-				if err != nil {
-					//jlog.Error(err)
-					return err
-				}
+			tmpCommand, err := c.App.resolveCommand(inputCmdStr)
+			if err != nil {
+				return err
 			}
-			// JC 220512: 初始化jargMaps
-			if tmpCommand.jargMaps == nil {
-				tmpCommand.jargMaps = make(ArgMap)
+			if tmpCommand == c.App.currentCmd {
+				return nil
 			}
-			// [+]220521: Add 设置自动填充参数
-			//jlog.Error(c.App.currentCmd)
-			if c.App.currentCmd == nil {
-				//jlog.Error("app.commands.list:", c.App.commands.list)
-				c.App.rl.Config.AutoComplete = newCompleter(&c.App.commands, c.App.currentCmd)
-			} else {
-				//jlog.Error("cmd.commands.list:",c.App.currentCmd.Name, c.App.currentCmd.commands.list)
-				c.App.rl.Config.AutoComplete = newCompleter(&c.App.currentCmd.commands, c.App.currentCmd)
+			if tmpCommand != nil && inputCmdStr != "-" {
+				tmpCommand.previousCmd = c.App.currentCmd // 记录切换前的command
 			}
-			//for _, v := range c.App.currentCmd.commands.list {
-			//	jlog.Warn("subCommand:", v.Name)
-			//}
-			return nil
+			return c.App.switchCommand(tmpCommand)
 		},
 		isBuiltin: true,
 	}
@@ -160,67 +125,34 @@ func core_show(a *App) *Command {
 		Help:      "show options",
 		LongHelp:  "",
 		HelpGroup: jconfig.CORE_COMMAND_STR,
-		Usage:     "show options",
+		Usage:     "show [options|missing|changed|info|all|global]",
 		Flags:     nil,
-		Args:      nil,
+		Args: func(a *Args) {
+			a.String("target", "options, missing, changed, info, all or global", Default("options"))
+		},
 		Run: func(c *Context) error {
+			switch target := c.Args.String("target"); target {
+			case showAllOptions, showMissingOptions, showChangedOptions, "info":
+			case "all":
+				showAll(c)
+				return nil
+			case "global":
+				showGlobals(c)
+				return nil
+			default:
+				return fmt.Errorf("invalid show target: %s", target)
+			}
 			// 获取当前command
 			tmpCommand := c.App.currentCmd
 			if tmpCommand == nil {
 				//jlog.Errorf("error: command u input not exist\n")
 				return fmt.Errorf("error: CurrentCommond is %v", tmpCommand)
 			}
-			// 输出当前flag
-			t := table.NewWriter()
-			t.AppendHeader(table.Row{"Name", "Value", "type", "Description"})
-			//a.Printf("%-10v%-30v%-10v%-10v%v\n", "name", "value", "type", "isDefault", "description")
-			//a.Println("=======================================================================")
-			// JC 220512 遍历输出flag
-			for _, v := range tmpCommand.flags.list {
-				// JC 220514: 过滤掉help flag
-				if v.Long == "help" {
-					continue
-				}
-				if "slice" == reflect.TypeOf(tmpCommand.jflagMaps[v.Long].Value).Kind().String() {
-					tmpStrSlice := make([]string, len(tmpCommand.jflagMaps[v.Long].Value.([]interface{})))
-					for k2, v2 := range tmpCommand.jflagMaps[v.Long].Value.([]interface{}) {
-						tmpStrSlice[k2] = fmt.Sprintf("%v", v2)
-					}
-					t.AppendRow(table.Row{v.Long, fmt.Sprintf("[%s]", strings.Join(tmpStrSlice, " ")), "flag", v.HelpArgs + ". " + v.Help})
-					//a.Printf("%-10v%-30v%-10v%v\n", v.Long, "["+strings.Join(tmpStrSlice, " ")+"]", "flag", v.HelpArgs+". "+v.Help)
-				} else {
-					t.AppendRow(table.Row{v.Long, tmpCommand.jflagMaps[v.Long].Value, "flag", v.HelpArgs + ". " + v.Help})
-					//a.Printf("%-10v%-30v%-10v%v\n", v.Long, tmpCommand.jflagMaps[v.Long].Value, "flag", v.HelpArgs+". "+v.Help)
-				}
-			}
-			t.AppendSeparator()
-			// JC 220512 遍历输出args
-			for _, v := range tmpCommand.args.list {
-				tmpStrSliceLen := 0
-				tmpArg := reflect.Value{}
-				tmpArgValue := ""
-				// JC 220515: 判断是否设置
-				if _, ok := tmpCommand.jargMaps[v.Name]; ok {
-					// 判断是否list
-					if v.isList {
-						tmpArg = reflect.ValueOf(tmpCommand.jargMaps[v.Name].Value)
-						tmpStrSliceLen = tmpArg.Len()
-						tmpStrSlice := make([]string, tmpStrSliceLen)
-						for k2, _ := range tmpStrSlice {
-							tmpStrSlice[k2] = fmt.Sprintf("%v", tmpArg.Index(k2))
-						}
-						tmpArgValue = "[" + strings.Join(tmpStrSlice, " ") + "]"
-					} else {
-						tmpArgValue = fmt.Sprintf("%v", tmpCommand.jargMaps[v.Name].Value)
-					}
-					t.AppendRow(table.Row{v.Name, tmpArgValue, "arg", v.HelpArgs + ". " + v.Help})
-					//a.Printf("%-10v%-30v%-10v%v\n", v.Name, tmpArgValue, "arg", v.HelpArgs+". "+v.Help)
-				} else {
-					t.AppendRow(table.Row{v.Name, "", "arg", v.HelpArgs + ". " + v.Help})
-					//a.Printf("%-10v%-30v%-10v%v\n", v.Name, "", "arg", v.HelpArgs+". "+v.Help)
-				}
+			if target := c.Args.String("target"); target == "info" {
+				showInfo(c, tmpCommand)
+			} else {
+				showOptions(c, tmpCommand, target)
 			}
-			a.Println(t.Render())
 			return nil
 		},
 		isBuiltin: true,
@@ -272,22 +204,12 @@ func core_setf(a *App) *Command {
 				return err
 			}
 			argValue = splitArgs[0]
-			// 判断argName是否在当前命令的flag中
-			for _, v := range tmpCommand.flags.list {
-				if tmpCommand.jflagMaps == nil {
-					tmpCommand.jflagMaps = make(FlagMap)
-				}
-				if argName == v.Long {
-					// DONE 解析flag
-					_, err := tmpCommand.flags.parse([]string{"--" + argName + "=" + argValue}, tmpCommand.jflagMaps)
-					if err != nil {
-						//jlog.Error(err)
-						return err
-					}
-					break
-				}
+			// 解析flag
+			err = tmpCommand.setFlag(argName, argValue)
+			if err != nil {
+				return err
 			}
-			//jlog.Debug(tmpCommand.jflagMaps)
+			delete(tmpCommand.globalOpts, argName)
 			return nil
 		},
 		isBuiltin: true,
@@ -345,26 +267,12 @@ func core_seta(a *App) *Command {
 			}
 			argValue = splitArgs[0]
 			//jlog.Info("argValue:", argValue)
-			// 判断argName是否在当前命令的arg中
-			for _, v := range tmpCommand.args.list {
-				if argName == v.Name {
-					if tmpCommand.jargMaps == nil {
-						tmpCommand.jargMaps = make(ArgMap)
-					}
-
-					// 解析arg
-					_, err := v.parser([]string{argValue}, tmpCommand.jargMaps)
-					if err != nil {
-						return err
-					}
-					//tmpCommand.jargMaps[v.Name] = &ArgMapItem{
-					//	Value:     nil,
-					//	IsDefault: false,
-					//}
-					break
-				}
-
+			// 解析arg
+			err := tmpCommand.setArg(argName, argValue)
+			if err != nil {
+				return err
 			}
+			delete(tmpCommand.globalOpts, argName)
 			return nil
 		},
 		isBuiltin: true,
@@ -379,9 +287,11 @@ func core_run(a *App) *Command {
 		Help:      "run current command",
 		LongHelp:  "",
 		HelpGroup: jconfig.CORE_COMMAND_STR,
-		Usage:     "run",
-		Flags:     nil,
-		Args:      nil,
+		Usage:     "run [-j]",
+		Flags: func(f *Flags) {
+			f.Bool("j", "job", false, "run the command as background job")
+		},
+		Args: nil,
 		Run: func(c *Context) error {
 			// 获取当前command
 			tmpCommand := c.App.currentCmd
@@ -394,15 +304,22 @@ func core_run(a *App) *Command {
 				c.App.printCommandHelp(c.App, tmpCommand, c.App.isShell, true)
 				return nil
 			}
-			// 执行前判断arg是否全部赋值
-			for _, v := range tmpCommand.args.list {
-				if _, ok := tmpCommand.jargMaps[v.Name]; !ok {
-					return fmt.Errorf("请为所有arg类型的参数赋值")
-				}
+			// 执行前判断必需的arg是否全部赋值
+			if missing := tmpCommand.missingOptions(); len(missing) > 0 {
+				return fmt.Errorf("missing required options: %s, see 'show missing'", strings.Join(missing, ", "))
+			}
+			if err := tmpCommand.flags.checkGroups(tmpCommand.jflagMaps); err != nil {
+				return err
 			}
 			// 执行
-			ctx := newContext(c.App, tmpCommand, tmpCommand.jflagMaps, tmpCommand.jargMaps)
-			err := tmpCommand.Run(ctx)
+			ctx := newContext(c.App, tmpCommand, tmpCommand.jflagMaps, tmpCommand.runArgs())
+			ctx.stdin = c.stdin
+			ctx.stdout = c.stdout
+			if c.Flags.Bool("job") {
+				c.App.startJob(ctx)
+				return nil
+			}
+			err := c.App.runForeground(ctx)
 			if err != nil {
 				return err
 			}
@@ -435,39 +352,7 @@ func core_back(a *App) *Command {
 			if c.App.currentCmd == nil {
 				return nil
 			}
-			//jlog.Warn("currentCmd:", c.App.currentCmd)
-			c.App.currentCmd = c.App.currentCmd.previousCmd
-			if c.App.currentCmd == nil {
-				c.App.currentPrompt = c.App.config.Name + " >> "
-			} else {
-				//jlog.Warn("previousCmd:", c.App.currentCmd)
-				c.App.currentPrompt = c.App.config.Name + fmt.Sprintf(" %s(%s) >>", c.App.currentCmd.Name, c.App.currentCmd.parentPath)
-			}
-			//jlog.Warn("currentPrompt:", c.App.currentPrompt)
-			c.App.SetPrompt(c.App.currentPrompt)
-			// [+]220521: Add 设置自动填充参数
-			if c.App.currentCmd == nil {
-				//jlog.Error("app:", c.App.commands.list)
-				c.App.rl.Config.AutoComplete = newCompleter(&c.App.commands, c.App.currentCmd)
-			} else {
-				// 初始化jflagMaps
-				if c.App.currentCmd.jflagMaps == nil {
-					c.App.currentCmd.jflagMaps = make(FlagMap)
-					// flag会使用默认值进行初始化，(arg只有list有默认空值，不能用这种方法)
-					_, err := c.App.currentCmd.flags.parse([]string{}, c.App.currentCmd.jflagMaps)
-					if err != nil {
-						//jlog.Error(err)
-						return err
-					}
-				}
-				// JC 220512: 初始化jargMaps
-				if c.App.currentCmd.jargMaps == nil {
-					c.App.currentCmd.jargMaps = make(ArgMap)
-				}
-				//jlog.Error(c.App.currentCmd.Name, c.App.currentCmd.commands.list)
-				c.App.rl.Config.AutoComplete = newCompleter(&c.App.currentCmd.commands, c.App.currentCmd)
-			}
-			return nil
+			return c.App.switchCommand(c.App.currentCmd.previousCmd)
 		},
 		isBuiltin: true,
 		Completer: nil,
@@ -503,15 +388,15 @@ func core_unsetf(a *App) *Command {
 			// 初始化flag
 			if arg == "all" { // 初始化每一个flag
 				for _, v := range tmpCommand.flags.list {
-					df := tmpCommand.flags.defaults[v.Long]
-					df(tmpCommand.jflagMaps)
+					tmpCommand.flags.reset(v.Long, tmpCommand.jflagMaps)
+					delete(tmpCommand.globalOpts, v.Long)
 				}
 				//jlog.Debug("unset all flag")
 			} else { // 初始化指定flag
 				for _, v := range tmpCommand.flags.list {
 					if v.Long == arg {
-						df := tmpCommand.flags.defaults[v.Long]
-						df(tmpCommand.jflagMaps)
+						tmpCommand.flags.reset(v.Long, tmpCommand.jflagMaps)
+						delete(tmpCommand.globalOpts, v.Long)
 						return nil
 					}
 				}
@@ -554,6 +439,7 @@ func core_unseta(a *App) *Command {
 				for _, v := range tmpCommand.args.list {
 					//删除对应arg的argMapItem
 					delete(tmpCommand.jargMaps, v.Name)
+					delete(tmpCommand.globalOpts, v.Name)
 					//df := tmpCommand.flags.defaults[v.Long]
 					//df(tmpCommand.jflagMaps)
 				}
@@ -564,6 +450,7 @@ func core_unseta(a *App) *Command {
 						//df := tmpCommand.flags.defaults[v.Long]
 						//df(tmpCommand.jflagMaps)
 						delete(tmpCommand.jargMaps, v.Name)
+						delete(tmpCommand.globalOpts, v.Name)
 						return nil
 					}
 				}
@@ -579,3 +466,512 @@ func core_unseta(a *App) *Command {
 		jflagMaps: nil,
 	}
 }
+
+func core_save(a *App) *Command {
+	return &Command{
+		Name:      "save",
+		Help:      "save the flags and args of all commands to a file",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "save [file]",
+		Args: func(a *Args) {
+			a.String("file", "state file, defaults to the configured state file", Default(""))
+		},
+		Run: func(c *Context) error {
+			file := c.Args.String("file")
+			if len(file) == 0 {
+				file = c.App.config.StateFile
+			}
+			if len(file) == 0 {
+				return fmt.Errorf("no state file specified")
+			}
+			err := c.App.saveState(file)
+			if err != nil {
+				return err
+			}
+			c.Printf("saved state to %s\n", file)
+			return nil
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_load(a *App) *Command {
+	return &Command{
+		Name:      "load",
+		Help:      "load the flags and args of all commands from a file",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "load [file]",
+		Args: func(a *Args) {
+			a.String("file", "state file, defaults to the configured state file", Default(""))
+		},
+		Run: func(c *Context) error {
+			file := c.Args.String("file")
+			if len(file) == 0 {
+				file = c.App.config.StateFile
+			}
+			if len(file) == 0 {
+				return fmt.Errorf("no state file specified")
+			}
+			err := c.App.loadState(file)
+			if err != nil {
+				return err
+			}
+			c.Printf("loaded state from %s\n", file)
+			return nil
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_setg(a *App) *Command {
+	return &Command{
+		Name:      "setg",
+		Help:      "set global option",
+		LongHelp:  "set a global option, which is used by every flag or arg with the same name unless set locally",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "setg name value",
+		Args: func(a *Args) {
+			a.String("name", "flag or arg name")
+			a.String("value", "global value")
+		},
+		Run: func(c *Context) error {
+			return c.App.SetGlobal(c.Args.String("name"), c.Args.String("value"))
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_unsetg(a *App) *Command {
+	return &Command{
+		Name:      "unsetg",
+		Help:      "unset global option",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "unsetg <name>|all",
+		Args: func(a *Args) {
+			a.String("name", "global option name or all")
+		},
+		Run: func(c *Context) error {
+			name := c.Args.String("name")
+			if name == "all" {
+				for k := range c.App.Globals() {
+					c.App.UnsetGlobal(k)
+				}
+				return nil
+			}
+			if _, ok := c.App.globals[name]; !ok {
+				return fmt.Errorf("global option %s not set", name)
+			}
+			c.App.UnsetGlobal(name)
+			return nil
+		},
+		isBuiltin: true,
+	}
+}
+
+// showGlobals prints all global option values.
+func showGlobals(c *Context) {
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Name", "Value"})
+	for _, k := range sortedKeys(c.App.globals) {
+		t.AppendRow(table.Row{k, c.App.globals[k]})
+	}
+	c.Println(t.Render())
+}
+
+func core_resource(a *App) *Command {
+	return &Command{
+		Name:      "resource",
+		Help:      "execute the commands of a resource file",
+		LongHelp:  "execute each line of the file as if typed in the shell. Lines starting with '#' are comments",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "resource [-c] file",
+		Flags: func(f *Flags) {
+			f.Bool("c", "continue", false, "continue with the next line if a command fails")
+		},
+		Args: func(a *Args) {
+			a.String("file", "resource file")
+		},
+		Run: func(c *Context) error {
+			file, err := unquoteValue(c.Args.String("file"))
+			if err != nil {
+				return err
+			}
+			return c.App.RunResource(file, c.Flags.Bool("continue"))
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_jobs(a *App) *Command {
+	return &Command{
+		Name:      "jobs",
+		Help:      "list and manage background jobs",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "jobs [-k id] [-o id]",
+		Flags: func(f *Flags) {
+			f.Int("k", "kill", 0, "kill the job with the id")
+			f.Int("o", "output", 0, "print the buffered output of the job with the id")
+		},
+		Run: func(c *Context) error {
+			if id := c.Flags.Int("kill"); id > 0 {
+				return c.App.killJob(id)
+			}
+			if id := c.Flags.Int("output"); id > 0 {
+				j := c.App.getJob(id)
+				if j == nil {
+					return fmt.Errorf("job %d not found", id)
+				}
+				c.Print(j.output.String())
+				return nil
+			}
+
+			t := table.NewWriter()
+			t.AppendHeader(table.Row{"ID", "Command", "Started", "Status", "Duration"})
+			c.App.jobs.mu.Lock()
+			for _, j := range c.App.jobs.list {
+				end := j.end
+				if end.IsZero() {
+					end = time.Now()
+				}
+				status := j.status
+				if j.err != nil {
+					status += ": " + j.err.Error()
+				}
+				t.AppendRow(table.Row{j.id, j.path, j.start.Format("15:04:05"), status, end.Sub(j.start).Round(time.Millisecond)})
+			}
+			c.App.jobs.mu.Unlock()
+			c.Println(t.Render())
+			return nil
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_let(a *App) *Command {
+	return &Command{
+		Name:      "let",
+		Help:      "set a variable",
+		LongHelp:  "set a variable, which can be referenced as $name or ${name}",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "let name=value",
+		Args: func(a *Args) {
+			a.String("assignment", "name=value")
+		},
+		Run: func(c *Context) error {
+			assignment := c.Args.String("assignment")
+			pos := strings.Index(assignment, "=")
+			if pos <= 0 {
+				return fmt.Errorf("invalid assignment, use: let name=value")
+			}
+			value, err := unquoteValue(assignment[pos+1:])
+			if err != nil {
+				return err
+			}
+			return c.App.SetVar(assignment[:pos], value)
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_unset(a *App) *Command {
+	return &Command{
+		Name:      "unset",
+		Help:      "unset a variable",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "unset name",
+		Args: func(a *Args) {
+			a.String("name", "variable name")
+		},
+		Run: func(c *Context) error {
+			name := c.Args.String("name")
+			if _, ok := c.App.vars[name]; !ok {
+				return fmt.Errorf("variable %s not set", name)
+			}
+			c.App.UnsetVar(name)
+			return nil
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_vars(a *App) *Command {
+	return &Command{
+		Name:      "vars",
+		Help:      "list all variables",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "vars",
+		Run: func(c *Context) error {
+			t := table.NewWriter()
+			t.AppendHeader(table.Row{"Name", "Value"})
+			for _, k := range sortedKeys(c.App.vars) {
+				t.AppendRow(table.Row{k, c.App.vars[k]})
+			}
+			c.Println(t.Render())
+			return nil
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_alias(a *App) *Command {
+	return &Command{
+		Name:      "alias",
+		Help:      "define or list aliases",
+		LongHelp:  "define an alias for a shell line, which can reference its arguments with $1..$9 and $@, e.g. alias quick=\"use cdn; seta t $1; run\"",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "alias [name[=line]]",
+		Args: func(a *Args) {
+			a.String("definition", "name=line, lists all aliases if empty", Default(""))
+		},
+		Run: func(c *Context) error {
+			definition := c.Args.String("definition")
+			pos := strings.Index(definition, "=")
+			if pos < 0 {
+				t := table.NewWriter()
+				t.AppendHeader(table.Row{"Name", "Line"})
+				for _, k := range c.App.sortedAliasNames() {
+					if len(definition) == 0 || k == definition {
+						t.AppendRow(table.Row{k, c.App.aliases[k]})
+					}
+				}
+				if len(definition) > 0 && t.Length() == 0 {
+					return fmt.Errorf("alias %s not found", definition)
+				}
+				c.Println(t.Render())
+				return nil
+			}
+			body, err := unquoteValue(definition[pos+1:])
+			if err != nil {
+				return err
+			}
+			return c.App.SetAlias(definition[:pos], body)
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_unalias(a *App) *Command {
+	return &Command{
+		Name:      "unalias",
+		Help:      "remove an alias",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "unalias name",
+		Args: func(a *Args) {
+			a.String("name", "alias name")
+		},
+		Run: func(c *Context) error {
+			return c.App.UnsetAlias(c.Args.String("name"))
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_history(a *App) *Command {
+	return &Command{
+		Name:      "history",
+		Help:      "list the command history",
+		LongHelp:  "list the command history. Use !n, !-n, !! or !prefix to execute a line of the history again",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "history [-s text] [-c] [count]",
+		Flags: func(f *Flags) {
+			f.String("s", "search", "", "only list lines containing the text")
+			f.Bool("c", "clear", false, "clear the history")
+		},
+		Args: func(a *Args) {
+			a.Int("count", "number of lines to list, all if 0", Default(0))
+		},
+		Run: func(c *Context) error {
+			if c.Flags.Bool("clear") {
+				return c.App.clearHistory()
+			}
+			search := c.Flags.String("search")
+			count := c.Args.Int("count")
+			if count < 0 {
+				return fmt.Errorf("invalid count: %d", count)
+			}
+
+			// Collect the matching lines with their number.
+			var nums []int
+			for i, e := range c.App.history {
+				if strings.Contains(e.line, search) {
+					nums = append(nums, i+1)
+				}
+			}
+			if count > 0 && len(nums) > count {
+				nums = nums[len(nums)-count:]
+			}
+			for _, n := range nums {
+				e := c.App.history[n-1]
+				t := "-"
+				if !e.time.IsZero() {
+					t = e.time.Format("2006-01-02 15:04:05")
+				}
+				c.Printf("%5d  %-19s  %s\n", n, t, e.line)
+			}
+			return nil
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_spool(a *App) *Command {
+	return &Command{
+		Name:      "spool",
+		Help:      "write a transcript of the session to a file",
+		LongHelp:  "write all typed lines and the terminal output with timestamps to a file. Use 'spool off' to stop",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "spool [-a] [file|off]",
+		Flags: func(f *Flags) {
+			f.Bool("a", "append", false, "append to the file")
+		},
+		Args: func(a *Args) {
+			a.String("file", "spool file or off, shows the current spool file if empty", Default(""))
+		},
+		Run: func(c *Context) error {
+			file, err := unquoteValue(c.Args.String("file"))
+			if err != nil {
+				return err
+			}
+			switch file {
+			case "":
+				if path := c.App.spool.active(); len(path) > 0 {
+					c.Printf("spooling to %s\n", path)
+				} else {
+					c.Println("not spooling")
+				}
+				return nil
+			case "off":
+				if len(c.App.spool.active()) == 0 {
+					return fmt.Errorf("not spooling")
+				}
+				return c.App.spool.stop()
+			}
+			return c.App.spool.start(file, c.Flags.Bool("append"))
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_set(a *App) *Command {
+	return &Command{
+		Name:      "set",
+		Help:      "set or show the app settings",
+		LongHelp:  "set the app settings, e.g. set output json. Settings: output (" + strings.Join(outputFormats, ", ") + ")",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "set [name [value]]",
+		Args: func(a *Args) {
+			a.String("name", "name of the setting, shows all settings if empty", Default(""), Choices("output"))
+			a.String("value", "value of the setting, shows the value if empty", Default(""))
+		},
+		Run: func(c *Context) error {
+			name, value := c.Args.String("name"), c.Args.String("value")
+			switch {
+			case len(name) == 0:
+				return c.Emit([]string{"Name", "Value"}, [][]interface{}{{"output", c.App.Output()}})
+			case len(value) == 0:
+				c.Println(c.App.Output())
+				return nil
+			}
+			return c.App.SetOutput(value)
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_pwd(a *App) *Command {
+	return &Command{
+		Name:      "pwd",
+		Help:      "print the path of the current command",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "pwd",
+		Run: func(c *Context) error {
+			if c.App.currentCmd == nil {
+				c.Println("/")
+			} else {
+				c.Println(c.App.currentCmd.fullPath())
+			}
+			return nil
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_results(a *App) *Command {
+	return &Command{
+		Name:      "results",
+		Help:      "list, show and export the command results",
+		LongHelp:  "list, show and export the results of the previous commands. Use $results[n].path to pass a part of a result to another command, e.g. seta targets $results[3].ips",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "results [-e file] [-c] [index]",
+		Flags: func(f *Flags) {
+			f.String("e", "export", "", "export the results or the result with the index as JSON to the file")
+			f.Bool("c", "clear", false, "clear the results")
+		},
+		Args: func(a *Args) {
+			a.Int("index", "index of the result to show, all results are listed if 0", Default(0))
+		},
+		Run: func(c *Context) error {
+			if c.Flags.Bool("clear") {
+				return c.App.clearResults()
+			}
+			var data interface{} = c.App.results.list()
+			if index := c.Args.Int("index"); index != 0 {
+				e, ok := c.App.results.get(index)
+				if !ok {
+					return fmt.Errorf("result %d does not exist", index)
+				}
+				data = e.Data
+			}
+
+			if file := c.Flags.String("export"); len(file) > 0 {
+				b, err := json.MarshalIndent(data, "", "  ")
+				if err != nil {
+					return err
+				}
+				return ioutil.WriteFile(file, append(b, '\n'), 0600)
+			}
+			if raw, ok := data.(json.RawMessage); ok {
+				return c.Result(raw)
+			}
+
+			var rows [][]interface{}
+			for _, e := range c.App.results.list() {
+				summary := string(e.Data)
+				if r := []rune(summary); len(r) > 60 {
+					summary = string(r[:57]) + "..."
+				}
+				rows = append(rows, []interface{}{e.Index, e.Time.Format("2006-01-02 15:04:05"), e.Command, summary})
+			}
+			return c.Emit([]string{"Index", "Time", "Command", "Summary"}, rows)
+		},
+		isBuiltin: true,
+	}
+}
+
+func core_last(a *App) *Command {
+	return &Command{
+		Name:      "last",
+		Help:      "show the last command result",
+		LongHelp:  "show the last command result or the part of it selected by the gjson path, e.g. last hosts.#.ip",
+		HelpGroup: jconfig.CORE_COMMAND_STR,
+		Usage:     "last [path]",
+		Args: func(a *Args) {
+			a.String("path", "gjson path of the part to show", Default(""))
+		},
+		Run: func(c *Context) error {
+			e, ok := c.App.results.get(-1)
+			if !ok {
+				return fmt.Errorf("no results")
+			}
+			res := gjson.ParseBytes(e.Data)
+			if path := c.Args.String("path"); len(path) > 0 {
+				res = res.Get(path)
+				if !res.Exists() {
+					return fmt.Errorf("the last result has no value for '%s'", path)
+				}
+			}
+			return c.Result(json.RawMessage(res.Raw))
+		},
+		isBuiltin: true,
+	}
+}
@@ -0,0 +1,104 @@
+package jishell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of flag groups.
+const (
+	flagGroupExclusive = iota
+	flagGroupTogether
+	flagGroupOneRequired
+)
+
+// flagGroup is a constraint on a set of flags.
+type flagGroup struct {
+	kind  int
+	longs []string
+}
+
+// String describes the group for the help output.
+func (g *flagGroup) String() string {
+	names := "--" + strings.Join(g.longs, ", --")
+	switch g.kind {
+	case flagGroupExclusive:
+		return "mutually exclusive: " + names
+	case flagGroupTogether:
+		return "required together: " + names
+	default:
+		return "at least one of: " + names
+	}
+}
+
+// check validates the group against the parsed flags. Values of the environment
+// or the config file only count for required flags, as they are not set by the user.
+func (g *flagGroup) check(res FlagMap) error {
+	var set, unset []string
+	for _, long := range g.longs {
+		item, ok := res[long]
+		if ok && !item.IsDefault && (g.kind == flagGroupOneRequired || !item.overridable()) {
+			set = append(set, long)
+		} else {
+			unset = append(unset, long)
+		}
+	}
+	switch {
+	case g.kind == flagGroupExclusive && len(set) > 1:
+		return fmt.Errorf("flags --%s are mutually exclusive", strings.Join(set, ", --"))
+	case g.kind == flagGroupTogether && len(set) > 0 && len(unset) > 0:
+		return fmt.Errorf("flags --%s must be set together: missing --%s",
+			strings.Join(g.longs, ", --"), strings.Join(unset, ", --"))
+	case g.kind == flagGroupOneRequired && len(set) == 0:
+		return fmt.Errorf("at least one of the flags --%s is required", strings.Join(g.longs, ", --"))
+	}
+	return nil
+}
+
+// MutuallyExclusive declares, that at most one of the flags may be set.
+// Panics if a flag has not been registered.
+func (f *Flags) MutuallyExclusive(longs ...string) {
+	f.addGroup(flagGroupExclusive, longs)
+}
+
+// RequiredTogether declares, that either all or none of the flags must be set.
+// Panics if a flag has not been registered.
+func (f *Flags) RequiredTogether(longs ...string) {
+	f.addGroup(flagGroupTogether, longs)
+}
+
+// OneRequired declares, that at least one of the flags must be set.
+// Panics if a flag has not been registered.
+func (f *Flags) OneRequired(longs ...string) {
+	f.addGroup(flagGroupOneRequired, longs)
+}
+
+func (f *Flags) addGroup(kind int, longs []string) {
+	if len(longs) < 2 {
+		panic("flag group requires at least two flags")
+	}
+	for _, long := range longs {
+		found := false
+		for _, v := range f.list {
+			if v.Long == long {
+				found = true
+				break
+			}
+		}
+		if !found {
+			panic(fmt.Errorf("grouped flag '%s' is not registered", long))
+		}
+	}
+	f.groups = append(f.groups, &flagGroup{kind: kind, longs: longs})
+}
+
+// checkGroups validates all flag groups against the parsed flags.
+func (f *Flags) checkGroups(res FlagMap) error {
+	for _, g := range f.groups {
+		err := g.check(res)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -26,6 +26,8 @@ package jishell
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"time"
 )
 
@@ -33,6 +35,12 @@ import (
 type FlagMapItem struct {
 	Value     interface{}
 	IsDefault bool
+
+	// Source is the origin of the value, e.g. SourceCLI or SourceEnv.
+	Source string
+
+	// file is the path of the file a list has been read from.
+	file string
 }
 
 // FlagMap holds all the parsed flag values.
@@ -50,6 +58,16 @@ func (f FlagMap) copyMissingValues(m FlagMap, copyDefault bool) {
 	}
 }
 
+// clone returns a copy of the flag map, which can be used independently.
+func (f FlagMap) clone() FlagMap {
+	m := make(FlagMap, len(f))
+	for k, v := range f {
+		item := *v
+		m[k] = &item
+	}
+	return m
+}
+
 // display JC 240521显示所有的flag及设置的值
 func (f FlagMap) display() {
 	fmt.Println("----------------------------------------------")
@@ -196,3 +214,143 @@ func (f FlagMap) Duration(long string) time.Duration {
 	}
 	return v
 }
+
+// IP returns the given flag value as IP address.
+// Panics if not present. Flags must be registered.
+func (f FlagMap) IP(long string) net.IP {
+	i := f[long]
+	if i == nil {
+		panic(fmt.Errorf("missing flag value: flag '%s' not registered", long))
+	}
+	v, ok := i.Value.(net.IP)
+	if !ok {
+		panic(fmt.Errorf("failed to assert flag '%s' to IP address", long))
+	}
+	return v
+}
+
+// IPList returns the given flag value as IP address list.
+// Panics if not present. Flags must be registered.
+func (f FlagMap) IPList(long string) []net.IP {
+	i := f[long]
+	if i == nil {
+		panic(fmt.Errorf("missing flag value: flag '%s' not registered", long))
+	}
+	v, ok := i.Value.([]net.IP)
+	if !ok {
+		panic(fmt.Errorf("failed to assert flag '%s' to IP address list", long))
+	}
+	return v
+}
+
+// CIDR returns the given flag value as CIDR network.
+// Panics if not present. Flags must be registered.
+func (f FlagMap) CIDR(long string) *net.IPNet {
+	i := f[long]
+	if i == nil {
+		panic(fmt.Errorf("missing flag value: flag '%s' not registered", long))
+	}
+	v, ok := i.Value.(*net.IPNet)
+	if !ok {
+		panic(fmt.Errorf("failed to assert flag '%s' to CIDR network", long))
+	}
+	return v
+}
+
+// CIDRList returns the given flag value as CIDR network list.
+// Panics if not present. Flags must be registered.
+func (f FlagMap) CIDRList(long string) []*net.IPNet {
+	i := f[long]
+	if i == nil {
+		panic(fmt.Errorf("missing flag value: flag '%s' not registered", long))
+	}
+	v, ok := i.Value.([]*net.IPNet)
+	if !ok {
+		panic(fmt.Errorf("failed to assert flag '%s' to CIDR network list", long))
+	}
+	return v
+}
+
+// HostPort returns the given flag value as host:port.
+// Panics if not present. Flags must be registered.
+func (f FlagMap) HostPort(long string) HostPort {
+	i := f[long]
+	if i == nil {
+		panic(fmt.Errorf("missing flag value: flag '%s' not registered", long))
+	}
+	v, ok := i.Value.(HostPort)
+	if !ok {
+		panic(fmt.Errorf("failed to assert flag '%s' to host:port", long))
+	}
+	return v
+}
+
+// HostPortList returns the given flag value as host:port list.
+// Panics if not present. Flags must be registered.
+func (f FlagMap) HostPortList(long string) []HostPort {
+	i := f[long]
+	if i == nil {
+		panic(fmt.Errorf("missing flag value: flag '%s' not registered", long))
+	}
+	v, ok := i.Value.([]HostPort)
+	if !ok {
+		panic(fmt.Errorf("failed to assert flag '%s' to host:port list", long))
+	}
+	return v
+}
+
+// PortRange returns the given flag value as port range.
+// Panics if not present. Flags must be registered.
+func (f FlagMap) PortRange(long string) PortRange {
+	i := f[long]
+	if i == nil {
+		panic(fmt.Errorf("missing flag value: flag '%s' not registered", long))
+	}
+	v, ok := i.Value.(PortRange)
+	if !ok {
+		panic(fmt.Errorf("failed to assert flag '%s' to port range", long))
+	}
+	return v
+}
+
+// PortRangeList returns the given flag value as port range list.
+// Panics if not present. Flags must be registered.
+func (f FlagMap) PortRangeList(long string) []PortRange {
+	i := f[long]
+	if i == nil {
+		panic(fmt.Errorf("missing flag value: flag '%s' not registered", long))
+	}
+	v, ok := i.Value.([]PortRange)
+	if !ok {
+		panic(fmt.Errorf("failed to assert flag '%s' to port range list", long))
+	}
+	return v
+}
+
+// URL returns the given flag value as URL.
+// Panics if not present. Flags must be registered.
+func (f FlagMap) URL(long string) *url.URL {
+	i := f[long]
+	if i == nil {
+		panic(fmt.Errorf("missing flag value: flag '%s' not registered", long))
+	}
+	v, ok := i.Value.(*url.URL)
+	if !ok {
+		panic(fmt.Errorf("failed to assert flag '%s' to URL", long))
+	}
+	return v
+}
+
+// URLList returns the given flag value as URL list.
+// Panics if not present. Flags must be registered.
+func (f FlagMap) URLList(long string) []*url.URL {
+	i := f[long]
+	if i == nil {
+		panic(fmt.Errorf("missing flag value: flag '%s' not registered", long))
+	}
+	v, ok := i.Value.([]*url.URL)
+	if !ok {
+		panic(fmt.Errorf("failed to assert flag '%s' to URL list", long))
+	}
+	return v
+}
@@ -26,7 +26,9 @@ package jishell
 
 import (
 	"fmt"
-	shlex "github.com/chroblert/go-shlex"
+	"net"
+	"net/url"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -43,6 +45,10 @@ type flagItem struct {
 	HelpArgs        string
 	HelpShowDefault bool
 	Default         interface{}
+
+	required bool
+	rules    valueRules
+	env      string
 }
 
 // Flags holds all the registered flags.
@@ -50,6 +56,10 @@ type Flags struct {
 	parsers  []parseFlagFunc
 	defaults map[string]defaultFlagFunc
 	list     []*flagItem
+	groups   []*flagGroup
+
+	// fileValue looks up the value of a flag in the config file.
+	fileValue func(long string) (string, bool)
 }
 
 // empty returns true, if the flags are empty.
@@ -77,6 +87,7 @@ func (f *Flags) register(
 	defaultValue interface{},
 	df defaultFlagFunc,
 	pf parseFlagFunc,
+	opts ...FlagOption,
 ) {
 	// Validate.
 	// 校验名称是否有效
@@ -106,14 +117,18 @@ func (f *Flags) register(
 		}
 	}
 
-	f.list = append(f.list, &flagItem{
+	item := &flagItem{
 		Short:           short,
 		Long:            long,
 		Help:            help,
 		HelpShowDefault: helpShowDefault,
 		HelpArgs:        helpArgs, // flag的类型
 		Default:         defaultValue,
-	})
+	}
+	for _, opt := range opts {
+		opt.applyFlag(item)
+	}
+	f.list = append(f.list, item)
 
 	if f.defaults == nil {
 		f.defaults = make(map[string]defaultFlagFunc)
@@ -123,6 +138,45 @@ func (f *Flags) register(
 	f.parsers = append(f.parsers, pf)
 }
 
+// Required marks the flags as mandatory. A required flag must be passed on
+// the command line or set by setf before the command runs.
+// Panics if a flag has not been registered.
+func (f *Flags) Required(longs ...string) {
+	for _, long := range longs {
+		var item *flagItem
+		for _, v := range f.list {
+			if v.Long == long {
+				item = v
+				break
+			}
+		}
+		if item == nil {
+			panic(fmt.Errorf("required flag '%s' is not registered", long))
+		}
+		item.required = true
+	}
+}
+
+// missing returns the long names of the required flags, which have not been set.
+func (f *Flags) missing(res FlagMap) (names []string) {
+	for _, v := range f.list {
+		if item, ok := res[v.Long]; v.required && (!ok || item.IsDefault) {
+			names = append(names, v.Long)
+		}
+	}
+	return
+}
+
+// validate checks, that all required flags have been set
+// and that the flag groups are satisfied.
+func (f *Flags) validate(res FlagMap) error {
+	names := f.missing(res)
+	if len(names) > 0 {
+		return fmt.Errorf("missing required flags: --%s", strings.Join(names, ", --"))
+	}
+	return f.checkGroups(res)
+}
+
 // 判断传入的flag(eg. samples.exe add -s xx,samples.exe add --long xx),是否是之前设定的short，long
 func (f *Flags) match(flag, short, long string) bool {
 	return (len(short) > 0 && flag == "-"+short) ||
@@ -137,7 +191,8 @@ func (f *Flags) parse(args []string, res FlagMap) ([]string, error) {
 Loop:
 	for len(args) > 0 {
 		a := args[0]
-		if !strings.HasPrefix(a, "-") {
+		// A single dash is a positional argument, e.g. 'use -'.
+		if !strings.HasPrefix(a, "-") || a == "-" {
 			break Loop
 		}
 		args = args[1:]
@@ -168,6 +223,19 @@ Loop:
 		return nil, fmt.Errorf("invalid flag: %s", a)
 	}
 
+	// Check the passed values against the rules of the flags.
+	for _, i := range f.list {
+		if item, ok := res[i.Long]; ok && !item.IsDefault {
+			err = i.rules.check("flag '"+i.Long+"'", unquotedValue(item.Value))
+			if err != nil {
+				return nil, err
+			}
+			if len(item.Source) == 0 {
+				item.Source = SourceCLI
+			}
+		}
+	}
+
 	// Finally set all the default values for not passed flags.
 	// 判断是否有flag
 	if f.defaults == nil {
@@ -180,24 +248,23 @@ Loop:
 			continue
 		}
 		// 若没有赋值
-		// 则尝试赋默认值
-		df, ok := f.defaults[i.Long]
-		if !ok {
-			return nil, fmt.Errorf("invalid flag: missing default function: %s", i.Long)
+		// 则尝试赋环境变量、配置文件中的值或默认值
+		err = f.setDefault(i, res)
+		if err != nil {
+			return nil, err
 		}
-		df(res)
 	}
 
 	return args, nil
 }
 
 // StringL same as String, but without a shorthand.
-func (f *Flags) StringL(long, defaultValue, help string) {
-	f.String("", long, defaultValue, help)
+func (f *Flags) StringL(long, defaultValue, help string, opts ...FlagOption) {
+	f.String("", long, defaultValue, help, opts...)
 }
 
 // String registers a string flag.
-func (f *Flags) String(short, long, defaultValue, help string) {
+func (f *Flags) String(short, long, defaultValue, help string, opts ...FlagOption) {
 	f.register(short, long, help, "string", true, defaultValue,
 		func(res FlagMap) {
 			res[long] = &FlagMapItem{
@@ -227,12 +294,12 @@ func (f *Flags) String(short, long, defaultValue, help string) {
 			}
 			args = args[1:]
 			return args, true, nil
-		})
+		}, opts...)
 }
 
 //210520: JC0o0l Add
 // String registers a string flag.
-func (f *Flags) StringList(short, long string, defaultValue []string, help string) {
+func (f *Flags) StringList(short, long string, defaultValue []string, help string, opts ...FlagOption) {
 	f.register(short, long, help, "string list", true, defaultValue,
 		func(res FlagMap) {
 			res[long] = &FlagMapItem{
@@ -256,21 +323,27 @@ func (f *Flags) StringList(short, long string, defaultValue []string, help strin
 				//	Value:     trimQuotes(equalVal),
 				//	IsDefault: false,
 				//}
-				splitArgs, err := shlex.Split(equalVal, true, false, ',')
+				splitArgs, file, err := splitList(equalVal, flagListFile(opts))
 				if err != nil {
 					return nil, false, err
 				}
 				if res[long] != nil && res[long].Value != nil {
+					// A list read from a file is only shown as such, if nothing else has been added.
+					if len(res[long].Value.([]interface{})) > 0 {
+						file = ""
+					}
 					// JC 220513: 以,分割
 					for _, str := range splitArgs {
 						res[long].Value = append(res[long].Value.([]interface{}), str)
 					}
 					res[long].IsDefault = false
+					res[long].file = file
 				} else {
 					// TODO 待完善：是否可以直接赋值？？
 					res[long] = &FlagMapItem{
 						Value:     make([]interface{}, 0),
 						IsDefault: false,
+						file:      file,
 					}
 					for _, str := range splitArgs {
 						res[long].Value = append(res[long].Value.([]interface{}), str)
@@ -283,7 +356,7 @@ func (f *Flags) StringList(short, long string, defaultValue []string, help strin
 				return args, false, fmt.Errorf("missing string value for flag: %s", flag)
 			}
 			//jlog.Error(args[0])
-			splitArgs, err := shlex.Split(args[0], true, false, ',')
+			splitArgs, file, err := splitList(args[0], flagListFile(opts))
 			if err != nil {
 				return nil, false, err
 			}
@@ -292,14 +365,19 @@ func (f *Flags) StringList(short, long string, defaultValue []string, help strin
 				//	Value:     append(res[long].Value.([]interface{}),args[0]),
 				//	IsDefault: false,
 				//}
+				if len(res[long].Value.([]interface{})) > 0 {
+					file = ""
+				}
 				for _, str := range splitArgs {
 					res[long].Value = append(res[long].Value.([]interface{}), str)
 				}
 				res[long].IsDefault = false
+				res[long].file = file
 			} else {
 				res[long] = &FlagMapItem{
 					Value:     make([]interface{}, 0),
 					IsDefault: false,
+					file:      file,
 				}
 				for _, str := range splitArgs {
 					res[long].Value = append(res[long].Value.([]interface{}), str)
@@ -308,17 +386,17 @@ func (f *Flags) StringList(short, long string, defaultValue []string, help strin
 			}
 			args = args[1:]
 			return args, true, nil
-		})
+		}, opts...)
 }
 
 //JC0o0l End
 // BoolL same as Bool, but without a shorthand.
-func (f *Flags) BoolL(long string, defaultValue bool, help string) {
-	f.Bool("", long, defaultValue, help)
+func (f *Flags) BoolL(long string, defaultValue bool, help string, opts ...FlagOption) {
+	f.Bool("", long, defaultValue, help, opts...)
 }
 
 // Bool registers a boolean flag.
-func (f *Flags) Bool(short, long string, defaultValue bool, help string) {
+func (f *Flags) Bool(short, long string, defaultValue bool, help string, opts ...FlagOption) {
 	f.register(short, long, help, "bool", false, defaultValue,
 		func(res FlagMap) {
 			res[long] = &FlagMapItem{
@@ -348,16 +426,16 @@ func (f *Flags) Bool(short, long string, defaultValue bool, help string) {
 				IsDefault: false,
 			}
 			return args, true, nil
-		})
+		}, opts...)
 }
 
 // IntL same as Int, but without a shorthand.
-func (f *Flags) IntL(long string, defaultValue int, help string) {
-	f.Int("", long, defaultValue, help)
+func (f *Flags) IntL(long string, defaultValue int, help string, opts ...FlagOption) {
+	f.Int("", long, defaultValue, help, opts...)
 }
 
 // Int registers an int flag.
-func (f *Flags) Int(short, long string, defaultValue int, help string) {
+func (f *Flags) Int(short, long string, defaultValue int, help string, opts ...FlagOption) {
 	f.register(short, long, help, "int", true, defaultValue,
 		func(res FlagMap) {
 			res[long] = &FlagMapItem{
@@ -387,16 +465,16 @@ func (f *Flags) Int(short, long string, defaultValue int, help string) {
 				IsDefault: false,
 			}
 			return args, true, nil
-		})
+		}, opts...)
 }
 
 // Int64L same as Int64, but without a shorthand.
-func (f *Flags) Int64L(long string, defaultValue int64, help string) {
-	f.Int64("", long, defaultValue, help)
+func (f *Flags) Int64L(long string, defaultValue int64, help string, opts ...FlagOption) {
+	f.Int64("", long, defaultValue, help, opts...)
 }
 
 // Int64 registers an int64 flag.
-func (f *Flags) Int64(short, long string, defaultValue int64, help string) {
+func (f *Flags) Int64(short, long string, defaultValue int64, help string, opts ...FlagOption) {
 	f.register(short, long, help, "int", true, defaultValue,
 		func(res FlagMap) {
 			res[long] = &FlagMapItem{
@@ -426,16 +504,16 @@ func (f *Flags) Int64(short, long string, defaultValue int64, help string) {
 				IsDefault: false,
 			}
 			return args, true, nil
-		})
+		}, opts...)
 }
 
 // UintL same as Uint, but without a shorthand.
-func (f *Flags) UintL(long string, defaultValue uint, help string) {
-	f.Uint("", long, defaultValue, help)
+func (f *Flags) UintL(long string, defaultValue uint, help string, opts ...FlagOption) {
+	f.Uint("", long, defaultValue, help, opts...)
 }
 
 // Uint registers an uint flag.
-func (f *Flags) Uint(short, long string, defaultValue uint, help string) {
+func (f *Flags) Uint(short, long string, defaultValue uint, help string, opts ...FlagOption) {
 	f.register(short, long, help, "uint", true, defaultValue,
 		func(res FlagMap) {
 			res[long] = &FlagMapItem{
@@ -465,16 +543,16 @@ func (f *Flags) Uint(short, long string, defaultValue uint, help string) {
 				IsDefault: false,
 			}
 			return args, true, nil
-		})
+		}, opts...)
 }
 
 // Uint64L same as Uint64, but without a shorthand.
-func (f *Flags) Uint64L(long string, defaultValue uint64, help string) {
-	f.Uint64("", long, defaultValue, help)
+func (f *Flags) Uint64L(long string, defaultValue uint64, help string, opts ...FlagOption) {
+	f.Uint64("", long, defaultValue, help, opts...)
 }
 
 // Uint64 registers an uint64 flag.
-func (f *Flags) Uint64(short, long string, defaultValue uint64, help string) {
+func (f *Flags) Uint64(short, long string, defaultValue uint64, help string, opts ...FlagOption) {
 	f.register(short, long, help, "uint", true, defaultValue,
 		func(res FlagMap) {
 			res[long] = &FlagMapItem{
@@ -504,16 +582,16 @@ func (f *Flags) Uint64(short, long string, defaultValue uint64, help string) {
 				IsDefault: false,
 			}
 			return args, true, nil
-		})
+		}, opts...)
 }
 
 // Float64L same as Float64, but without a shorthand.
-func (f *Flags) Float64L(long string, defaultValue float64, help string) {
-	f.Float64("", long, defaultValue, help)
+func (f *Flags) Float64L(long string, defaultValue float64, help string, opts ...FlagOption) {
+	f.Float64("", long, defaultValue, help, opts...)
 }
 
 // Float64 registers an float64 flag.
-func (f *Flags) Float64(short, long string, defaultValue float64, help string) {
+func (f *Flags) Float64(short, long string, defaultValue float64, help string, opts ...FlagOption) {
 	f.register(short, long, help, "float", true, defaultValue,
 		func(res FlagMap) {
 			res[long] = &FlagMapItem{
@@ -543,16 +621,16 @@ func (f *Flags) Float64(short, long string, defaultValue float64, help string) {
 				IsDefault: false,
 			}
 			return args, true, nil
-		})
+		}, opts...)
 }
 
 // DurationL same as Duration, but without a shorthand.
-func (f *Flags) DurationL(long string, defaultValue time.Duration, help string) {
-	f.Duration("", long, defaultValue, help)
+func (f *Flags) DurationL(long string, defaultValue time.Duration, help string, opts ...FlagOption) {
+	f.Duration("", long, defaultValue, help, opts...)
 }
 
 // Duration registers a duration flag.
-func (f *Flags) Duration(short, long string, defaultValue time.Duration, help string) {
+func (f *Flags) Duration(short, long string, defaultValue time.Duration, help string, opts ...FlagOption) {
 	f.register(short, long, help, "duration", true, defaultValue,
 		func(res FlagMap) {
 			res[long] = &FlagMapItem{
@@ -582,7 +660,206 @@ func (f *Flags) Duration(short, long string, defaultValue time.Duration, help st
 				IsDefault: false,
 			}
 			return args, true, nil
-		})
+		}, opts...)
+}
+
+// IPL same as IP, but without a shorthand.
+func (f *Flags) IPL(long string, defaultValue net.IP, help string, opts ...FlagOption) {
+	f.IP("", long, defaultValue, help, opts...)
+}
+
+// IP registers an IP address flag.
+func (f *Flags) IP(short, long string, defaultValue net.IP, help string, opts ...FlagOption) {
+	f.registerValue(short, long, help, "ip", defaultValue, parseIP, opts...)
+}
+
+// IPList registers a IP address list flag. The elements are separated by comma
+// and repeated flags are appended.
+func (f *Flags) IPList(short, long string, defaultValue []net.IP, help string, opts ...FlagOption) {
+	f.registerValueList(short, long, help, "ip list", defaultValue, parseIP, opts...)
+}
+
+// CIDRL same as CIDR, but without a shorthand.
+func (f *Flags) CIDRL(long string, defaultValue *net.IPNet, help string, opts ...FlagOption) {
+	f.CIDR("", long, defaultValue, help, opts...)
+}
+
+// CIDR registers a CIDR network flag.
+func (f *Flags) CIDR(short, long string, defaultValue *net.IPNet, help string, opts ...FlagOption) {
+	f.registerValue(short, long, help, "cidr", defaultValue, parseCIDR, opts...)
+}
+
+// CIDRList registers a CIDR network list flag. The elements are separated by comma
+// and repeated flags are appended.
+func (f *Flags) CIDRList(short, long string, defaultValue []*net.IPNet, help string, opts ...FlagOption) {
+	f.registerValueList(short, long, help, "cidr list", defaultValue, parseCIDR, opts...)
+}
+
+// HostPortL same as HostPort, but without a shorthand.
+func (f *Flags) HostPortL(long string, defaultValue HostPort, help string, opts ...FlagOption) {
+	f.HostPort("", long, defaultValue, help, opts...)
+}
+
+// HostPort registers a host:port flag.
+func (f *Flags) HostPort(short, long string, defaultValue HostPort, help string, opts ...FlagOption) {
+	f.registerValue(short, long, help, "host:port", defaultValue, parseHostPort, opts...)
+}
+
+// HostPortList registers a host:port list flag. The elements are separated by comma
+// and repeated flags are appended.
+func (f *Flags) HostPortList(short, long string, defaultValue []HostPort, help string, opts ...FlagOption) {
+	f.registerValueList(short, long, help, "host:port list", defaultValue, parseHostPort, opts...)
+}
+
+// PortRangeL same as PortRange, but without a shorthand.
+func (f *Flags) PortRangeL(long string, defaultValue PortRange, help string, opts ...FlagOption) {
+	f.PortRange("", long, defaultValue, help, opts...)
+}
+
+// PortRange registers a port range flag.
+func (f *Flags) PortRange(short, long string, defaultValue PortRange, help string, opts ...FlagOption) {
+	f.registerValue(short, long, help, "port range", defaultValue, parsePortRange, opts...)
+}
+
+// PortRangeList registers a port range list flag. The elements are separated by comma
+// and repeated flags are appended.
+func (f *Flags) PortRangeList(short, long string, defaultValue []PortRange, help string, opts ...FlagOption) {
+	f.registerValueList(short, long, help, "port range list", defaultValue, parsePortRange, opts...)
+}
+
+// URLL same as URL, but without a shorthand.
+func (f *Flags) URLL(long string, defaultValue *url.URL, help string, opts ...FlagOption) {
+	f.URL("", long, defaultValue, help, opts...)
+}
+
+// URL registers an URL flag.
+func (f *Flags) URL(short, long string, defaultValue *url.URL, help string, opts ...FlagOption) {
+	f.registerValue(short, long, help, "url", defaultValue, parseURL, opts...)
+}
+
+// URLList registers a URL list flag. The elements are separated by comma
+// and repeated flags are appended.
+func (f *Flags) URLList(short, long string, defaultValue []*url.URL, help string, opts ...FlagOption) {
+	f.registerValueList(short, long, help, "url list", defaultValue, parseURL, opts...)
+}
+
+// PathL same as Path, but without a shorthand.
+func (f *Flags) PathL(long, defaultValue, help string, opts ...FlagOption) {
+	f.Path("", long, defaultValue, help, opts...)
+}
+
+// Path registers a filesystem path flag, which is completed from the filesystem.
+// A leading ~ is expanded to the home directory.
+func (f *Flags) Path(short, long, defaultValue, help string, opts ...FlagOption) {
+	f.registerPath(short, long, defaultValue, help, "path", pathAny, opts)
+}
+
+// FileL same as File, but without a shorthand.
+func (f *Flags) FileL(long, defaultValue, help string, opts ...FlagOption) {
+	f.File("", long, defaultValue, help, opts...)
+}
+
+// File registers a file path flag. The path must not be a directory.
+func (f *Flags) File(short, long, defaultValue, help string, opts ...FlagOption) {
+	f.registerPath(short, long, defaultValue, help, "file", pathFile, opts)
+}
+
+// DirL same as Dir, but without a shorthand.
+func (f *Flags) DirL(long, defaultValue, help string, opts ...FlagOption) {
+	f.Dir("", long, defaultValue, help, opts...)
+}
+
+// Dir registers a directory path flag. The path must be a directory
+// and only directories are completed.
+func (f *Flags) Dir(short, long, defaultValue, help string, opts ...FlagOption) {
+	f.registerPath(short, long, defaultValue, help, "dir", pathDir, opts)
+}
+
+func (f *Flags) registerPath(short, long, defaultValue, help, helpArgs string, kind int, opts []FlagOption) {
+	opts = append([]FlagOption{pathKind(kind)}, opts...)
+	f.registerValue(short, long, help, helpArgs, expandHome(defaultValue), parseFlagPath, opts...)
+}
+
+// registerValue registers a flag, whose value is parsed by parse.
+func (f *Flags) registerValue(short, long, help, helpArgs string, defaultValue interface{}, parse func(s string) (interface{}, error), opts ...FlagOption) {
+	f.register(short, long, help, helpArgs, true, defaultValue,
+		func(res FlagMap) {
+			res[long] = &FlagMapItem{
+				Value:     defaultValue,
+				IsDefault: true,
+			}
+		},
+		func(flag, equalVal string, args []string, res FlagMap) ([]string, bool, error) {
+			if !f.match(flag, short, long) {
+				return args, false, nil
+			}
+			var vStr string
+			if len(equalVal) > 0 {
+				vStr = equalVal
+			} else if len(args) > 0 {
+				vStr = args[0]
+				args = args[1:]
+			} else {
+				return args, false, fmt.Errorf("missing %s value for flag: %s", helpArgs, flag)
+			}
+			v, err := parse(vStr)
+			if err != nil {
+				return args, false, fmt.Errorf("%v for flag: %s", err, flag)
+			}
+			res[long] = &FlagMapItem{
+				Value:     v,
+				IsDefault: false,
+			}
+			return args, true, nil
+		}, opts...)
+}
+
+// registerValueList registers a list flag, whose elements are parsed by parse.
+// The default value is a typed slice, which determines the type of the parsed list.
+func (f *Flags) registerValueList(short, long, help, helpArgs string, defaultValue interface{}, parse func(s string) (interface{}, error), opts ...FlagOption) {
+	f.register(short, long, help, helpArgs, true, defaultValue,
+		func(res FlagMap) {
+			res[long] = &FlagMapItem{
+				Value:     defaultValue,
+				IsDefault: true,
+			}
+		},
+		func(flag, equalVal string, args []string, res FlagMap) ([]string, bool, error) {
+			if !f.match(flag, short, long) {
+				return args, false, nil
+			}
+			var vStr string
+			if len(equalVal) > 0 {
+				vStr = equalVal
+			} else if len(args) > 0 {
+				vStr = args[0]
+				args = args[1:]
+			} else {
+				return args, false, fmt.Errorf("missing %s value for flag: %s", helpArgs, flag)
+			}
+			splitArgs, file, err := splitList(vStr, flagListFile(opts))
+			if err != nil {
+				return nil, false, err
+			}
+			// Repeated flags append to the previous values.
+			list := reflect.Zero(reflect.TypeOf(defaultValue)).Interface()
+			if res[long] != nil && !res[long].overridable() {
+				list = res[long].Value
+				if reflect.ValueOf(list).Len() > 0 {
+					file = ""
+				}
+			}
+			vs, err := parseValueList(list, splitArgs, parse)
+			if err != nil {
+				return args, false, fmt.Errorf("%v for flag: %s", err, flag)
+			}
+			res[long] = &FlagMapItem{
+				Value:     vs,
+				IsDefault: false,
+				file:      file,
+			}
+			return args, true, nil
+		}, opts...)
 }
 
 func trimQuotes(s string) string {
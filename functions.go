@@ -326,7 +326,7 @@ func printArgs(a *App, args *Args) {
 		if a.Default != nil && len(fmt.Sprintf("%v", a.Default)) > 0 && a.optional {
 			defaultValue = fmt.Sprintf("(default: %v)", a.Default)
 		}
-		output = append(output, fmt.Sprintf("%s || %s |||| %s %s", a.Name, a.HelpArgs, a.Help, defaultValue))
+		output = append(output, fmt.Sprintf("%s || %s |||| %s %s %s", a.Name, a.HelpArgs, a.Help, defaultValue, a.rules.String()))
 	}
 
 	if len(output) > 0 {
@@ -354,11 +354,13 @@ func printFlags(a *App, flags *Flags) {
 		}
 
 		defaultValue := ""
-		if f.Default != nil && f.HelpShowDefault && len(fmt.Sprintf("%v", f.Default)) > 0 {
+		if f.required {
+			defaultValue = "(required)"
+		} else if f.Default != nil && f.HelpShowDefault && len(fmt.Sprintf("%v", f.Default)) > 0 {
 			defaultValue = fmt.Sprintf("(default: %v)", f.Default)
 		}
 
-		output = append(output, fmt.Sprintf("%s | %s | %s |||| %s %s", short, long, f.HelpArgs, f.Help, defaultValue))
+		output = append(output, fmt.Sprintf("%s | %s | %s |||| %s %s %s", short, long, f.HelpArgs, f.Help, defaultValue, f.rules.String()))
 	}
 
 	if len(output) > 0 {
@@ -366,4 +368,13 @@ func printFlags(a *App, flags *Flags) {
 		printHeadline(a, "Flags:")
 		a.Printf("%s\n", columnize.Format(output, config))
 	}
+
+	// Flag groups.
+	if len(flags.groups) > 0 {
+		a.Println()
+		printHeadline(a, "Flag Groups:")
+		for _, g := range flags.groups {
+			a.Printf("  %s\n", g)
+		}
+	}
 }
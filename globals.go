@@ -0,0 +1,220 @@
+package jishell
+
+import (
+	"fmt"
+	"strings"
+
+	shlex "github.com/chroblert/go-shlex"
+)
+
+// SetGlobal sets a global option value. Every flag or arg with the same name
+// takes this value, unless it has been set locally.
+// The value is parsed by the parser of each flag or arg when it is applied.
+// If it can not be applied to a command in use, the previous value is restored.
+func (a *App) SetGlobal(name, value string) error {
+	if a.globals == nil {
+		a.globals = make(map[string]string)
+	}
+	prev, hadPrev := a.globals[name]
+	a.globals[name] = value
+
+	// Apply the value to all commands that are already in use.
+	var errs []string
+	a.commands.walk(func(cmd *Command) {
+		if cmd.isBuiltin || cmd.jflagMaps == nil {
+			return
+		}
+		err := a.applyGlobal(cmd, name)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	})
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if !hadPrev {
+		a.UnsetGlobal(name)
+	} else {
+		a.globals[name] = prev
+		a.commands.walk(func(cmd *Command) {
+			if cmd.isBuiltin || cmd.jflagMaps == nil {
+				return
+			}
+			_ = a.applyGlobal(cmd, name)
+		})
+	}
+	return fmt.Errorf("global '%s' not set:\n  %s", name, strings.Join(errs, "\n  "))
+}
+
+// UnsetGlobal removes a global option value. Flags and args, that took the
+// global value, are reset.
+func (a *App) UnsetGlobal(name string) {
+	delete(a.globals, name)
+	a.commands.walk(func(cmd *Command) {
+		if !cmd.globalOpts[name] {
+			return
+		}
+		delete(cmd.globalOpts, name)
+		for _, v := range cmd.flags.list {
+			if v.Long == name {
+				cmd.flags.reset(v.Long, cmd.jflagMaps)
+			}
+		}
+		delete(cmd.jargMaps, name)
+	})
+}
+
+// Globals returns a copy of all global option values.
+func (a *App) Globals() map[string]string {
+	m := make(map[string]string, len(a.globals))
+	for k, v := range a.globals {
+		m[k] = v
+	}
+	return m
+}
+
+// applyGlobals sets the global values for all flags and args of the
+// command, which have not been set locally. Flags and args, which can not
+// take a global value, are skipped and returned in the error.
+func (a *App) applyGlobals(cmd *Command) error {
+	if len(a.globals) == 0 {
+		return nil
+	}
+	err := cmd.initOptions()
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, name := range sortedKeys(a.globals) {
+		err = a.applyGlobal(cmd, name)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// applyGlobal sets the global value of the name for the flag and arg of the
+// command, if they have not been set locally. On invalid values, the flag and arg
+// are reset and the error is returned.
+func (a *App) applyGlobal(cmd *Command, name string) error {
+	raw, ok := a.globals[name]
+	if !ok || name == "help" {
+		return nil
+	}
+	err := cmd.initOptions()
+	if err != nil {
+		return err
+	}
+	if cmd.globalOpts == nil {
+		cmd.globalOpts = make(map[string]bool)
+	}
+
+	for _, v := range cmd.flags.list {
+		if v.Long != name {
+			continue
+		}
+		item := cmd.jflagMaps[v.Long]
+		if item != nil && !item.overridable() && !cmd.globalOpts[v.Long] {
+			break
+		}
+		value, err := unquoteValue(raw)
+		if err == nil {
+			delete(cmd.jflagMaps, v.Long)
+			err = cmd.setFlag(v.Long, value)
+		}
+		if err != nil {
+			cmd.flags.reset(v.Long, cmd.jflagMaps)
+			delete(cmd.globalOpts, v.Long)
+			return fmt.Errorf("%s: global '%s': %v", cmd.fullPath(), v.Long, err)
+		}
+		cmd.jflagMaps[v.Long].Source = SourceGlobal
+		cmd.globalOpts[v.Long] = true
+	}
+
+	for _, v := range cmd.args.list {
+		if v.Name != name {
+			continue
+		}
+		if _, isSet := cmd.jargMaps[v.Name]; isSet && !cmd.globalOpts[v.Name] {
+			break
+		}
+		value, err := raw, error(nil)
+		if !v.isList {
+			value, err = unquoteValue(raw)
+		}
+		if err == nil {
+			err = cmd.setArg(v.Name, value)
+		}
+		if err != nil {
+			delete(cmd.jargMaps, v.Name)
+			delete(cmd.globalOpts, v.Name)
+			return fmt.Errorf("%s: global '%s': %v", cmd.fullPath(), v.Name, err)
+		}
+		cmd.globalOpts[v.Name] = true
+	}
+	return nil
+}
+
+// applyGlobalFlags sets the global values for all flags of the command
+// in the parsed flag map, which have not been passed on the command line.
+// Invalid global values are skipped with a warning.
+func (a *App) applyGlobalFlags(cmd *Command, flags FlagMap) {
+	for _, v := range cmd.flags.list {
+		raw, ok := a.globals[v.Long]
+		if !ok || v.Long == "help" {
+			continue
+		}
+		if item := flags[v.Long]; item != nil && !item.overridable() {
+			continue
+		}
+		value, err := unquoteValue(raw)
+		res := make(FlagMap)
+		if err == nil {
+			_, err = cmd.flags.parse(flagArgs(v.Long, value), res)
+		}
+		if err != nil {
+			a.Printf("warning: skipped global '%s': %v\n", v.Long, err)
+			continue
+		}
+		flags[v.Long] = res[v.Long]
+		flags[v.Long].Source = SourceGlobal
+	}
+}
+
+// globalArg returns the global value for the arg, as expected by its parser.
+// Invalid global values are skipped with a warning.
+func (a *App) globalArg(item *argItem) (string, bool) {
+	raw, ok := a.globals[item.Name]
+	if !ok {
+		return "", false
+	}
+	value := raw
+	if !item.isList {
+		if v, err := unquoteValue(raw); err == nil {
+			value = v
+		}
+	}
+	_, err := item.parse([]string{value}, make(ArgMap))
+	if err != nil {
+		a.Printf("warning: skipped global '%s': %v\n", item.Name, err)
+		return "", false
+	}
+	return value, true
+}
+
+// unquoteValue removes the shell quotes of a value entered in the shell.
+func unquoteValue(raw string) (string, error) {
+	splitArgs, err := shlex.Split(raw, true, false)
+	if err != nil {
+		return "", err
+	}
+	if len(splitArgs) == 0 {
+		return "", nil
+	}
+	return splitArgs[0], nil
+}
@@ -13,4 +13,5 @@ require (
 	github.com/jedib0t/go-pretty/v6 v6.3.1
 	github.com/spf13/viper v1.11.0
 	github.com/tidwall/gjson v1.14.1
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,200 @@
+package jishell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyEntry is a line of the shell history.
+type historyEntry struct {
+	time time.Time // Zero if the history file has no timestamp for the line.
+	line string
+}
+
+// loadHistory reads the history file and passes the entries to readline.
+// The file uses the bash format: each line may be preceded by a comment
+// line with its unix timestamp, e.g. '#1650000000'.
+func (a *App) loadHistory() error {
+	if len(a.config.HistoryFile) == 0 || a.config.HistoryLimit < 0 {
+		return nil
+	}
+	f, err := os.Open(a.config.HistoryFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		entries []historyEntry
+		t       time.Time
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			if sec, err := strconv.ParseInt(line[1:], 10, 64); err == nil {
+				t = time.Unix(sec, 0)
+				continue
+			}
+		}
+		if len(line) > 0 {
+			entries = append(entries, historyEntry{time: t, line: line})
+		}
+		t = time.Time{}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	// Keep the file small.
+	truncated := len(entries) > a.config.HistoryLimit
+	if truncated {
+		entries = entries[len(entries)-a.config.HistoryLimit:]
+	}
+	a.history = entries
+	for _, e := range entries {
+		err = a.rl.SaveHistory(e.line)
+		if err != nil {
+			return err
+		}
+	}
+	if truncated {
+		return a.writeHistory(os.O_TRUNC, entries...)
+	}
+	return nil
+}
+
+// addHistory appends the line to the history and the history file.
+func (a *App) addHistory(line string) error {
+	if a.config.HistoryLimit < 0 {
+		return nil
+	}
+	e := historyEntry{time: time.Now(), line: line}
+	a.history = append(a.history, e)
+	if len(a.history) > a.config.HistoryLimit {
+		a.history = a.history[len(a.history)-a.config.HistoryLimit:]
+	}
+	err := a.rl.SaveHistory(line)
+	if err != nil {
+		return err
+	}
+	return a.writeHistory(os.O_APPEND, e)
+}
+
+// clearHistory removes all entries of the history and the history file.
+func (a *App) clearHistory() error {
+	a.history = nil
+	a.rl.ResetHistory()
+	return a.writeHistory(os.O_TRUNC)
+}
+
+// writeHistory writes the entries to the history file.
+// The mode is either os.O_APPEND or os.O_TRUNC.
+func (a *App) writeHistory(mode int, entries ...historyEntry) error {
+	if len(a.config.HistoryFile) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(a.config.HistoryFile, os.O_CREATE|os.O_WRONLY|mode, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, e := range entries {
+		if !e.time.IsZero() {
+			fmt.Fprintf(w, "#%d\n", e.time.Unix())
+		}
+		fmt.Fprintln(w, e.line)
+	}
+	err = w.Flush()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// expandHistory replaces the history references in the line:
+// '!!' is the previous line, '!n' the line n, '!-n' the n-th previous line
+// and '!prefix' the most recent line starting with prefix.
+// Single quoted text and escaped exclamation marks are kept literally.
+// It reports, whether the line has been changed.
+func (a *App) expandHistory(line string) (string, bool, error) {
+	var (
+		b        strings.Builder
+		quote    byte
+		expanded bool
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && quote != '\'' && i+1 < len(line):
+			b.WriteByte(c)
+			b.WriteByte(line[i+1])
+			i++
+			continue
+		case c == '\'' && quote != '"':
+			if quote == 0 {
+				quote = c
+			} else {
+				quote = 0
+			}
+		case c == '"' && quote != '\'':
+			if quote == 0 {
+				quote = c
+			} else {
+				quote = 0
+			}
+		case c == '!' && quote != '\'':
+			end := i + 1
+			if end < len(line) && line[end] == '!' {
+				end++
+			} else {
+				for end < len(line) && !strings.ContainsRune(" \t\"'=;|&>", rune(line[end])) {
+					end++
+				}
+			}
+			if end == i+1 {
+				break // A single '!' is kept.
+			}
+			entry, err := a.historyEvent(line[i+1 : end])
+			if err != nil {
+				return "", false, err
+			}
+			b.WriteString(entry)
+			expanded = true
+			i = end - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), expanded, nil
+}
+
+// historyEvent returns the history line referenced by the event without '!'.
+func (a *App) historyEvent(event string) (string, error) {
+	ref := event
+	if ref == "!" {
+		ref = "-1"
+	}
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 0 {
+			n = len(a.history) + n + 1
+		}
+		if n < 1 || n > len(a.history) {
+			return "", fmt.Errorf("!%s: event not found", event)
+		}
+		return a.history[n-1].line, nil
+	}
+	for i := len(a.history) - 1; i >= 0; i-- {
+		if strings.HasPrefix(a.history[i].line, event) {
+			return a.history[i].line, nil
+		}
+	}
+	return "", fmt.Errorf("!%s: event not found", event)
+}
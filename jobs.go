@@ -0,0 +1,163 @@
+package jishell
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Job status values.
+const (
+	jobRunning = "running"
+	jobKilling = "killing"
+	jobDone    = "done"
+	jobFailed  = "failed"
+	jobKilled  = "killed"
+)
+
+// job is a command running in the background.
+type job struct {
+	id     int
+	path   string
+	start  time.Time
+	end    time.Time
+	status string
+	err    error
+	cancel context.CancelFunc
+	output jobOutput
+}
+
+// jobOutput buffers the output of a background job.
+type jobOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *jobOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *jobOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// jobList holds all background jobs of the app.
+type jobList struct {
+	mu     sync.Mutex
+	list   []*job
+	lastID int
+}
+
+// startJob runs the command of the context in a goroutine as a numbered job.
+// The flags and args are copied, so that they can be changed while the job runs.
+func (a *App) startJob(ctx *Context) *job {
+	a.jobs.mu.Lock()
+	a.jobs.lastID++
+	j := &job{
+		id:     a.jobs.lastID,
+		path:   ctx.Command.fullPath(),
+		start:  time.Now(),
+		status: jobRunning,
+	}
+	a.jobs.list = append(a.jobs.list, j)
+	a.jobs.mu.Unlock()
+
+	jobCtx := newContext(a.jobApp(&j.output), ctx.Command, ctx.Flags.clone(), ctx.Args.clone())
+	jobCtx.ctx, j.cancel = context.WithCancel(context.Background())
+	jobCtx.stdout = &j.output
+
+	fmt.Fprintf(a.terminal(), "[%d] %s started\n", j.id, j.path)
+	go func() {
+		err := runJob(jobCtx)
+
+		a.jobs.mu.Lock()
+		j.end = time.Now()
+		j.err = err
+		switch {
+		case j.status == jobKilling:
+			j.status = jobKilled
+		case err != nil:
+			j.status = jobFailed
+		default:
+			j.status = jobDone
+		}
+		j.cancel()
+		a.jobs.mu.Unlock()
+
+		// Notify on the terminal, even if the current command is redirected.
+		if err != nil {
+			fmt.Fprintf(a.terminal(), "[%d] %s %s: %v\n", j.id, j.path, j.status, err)
+		} else {
+			fmt.Fprintf(a.terminal(), "[%d] %s %s\n", j.id, j.path, j.status)
+		}
+	}()
+	return j
+}
+
+// jobApp returns a copy of the app for a job, whose Print functions and Stdout
+// write to the job output, while the shell may redirect its own output meanwhile.
+// The jobs, results and spool file are shared with the app.
+func (a *App) jobApp(out io.Writer) *App {
+	ja := *a
+	ja.out = out
+	return &ja
+}
+
+// runJob runs the command and recovers from panics,
+// so that a failing job does not take down the shell.
+func runJob(ctx *Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return ctx.Command.Run(ctx)
+}
+
+// getJob returns the job with the id or nil.
+func (a *App) getJob(id int) *job {
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+	for _, j := range a.jobs.list {
+		if j.id == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// killJob cancels the context of a running job.
+func (a *App) killJob(id int) error {
+	j := a.getJob(id)
+	if j == nil {
+		return fmt.Errorf("job %d not found", id)
+	}
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+	if j.status != jobRunning {
+		return fmt.Errorf("job %d is not running", id)
+	}
+	j.status = jobKilling
+	j.cancel()
+	return nil
+}
+
+// killJobs cancels all running jobs.
+func (a *App) killJobs() error {
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+	for _, j := range a.jobs.list {
+		if j.status == jobRunning {
+			j.status = jobKilling
+			j.cancel()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package jishell
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// splitUnquoted splits the line at the operators, which are neither quoted
+// nor escaped. It returns the parts between the operators and the operators found.
+// Longer operators must be passed first, e.g. ">>" before ">".
+func splitUnquoted(line string, ops ...string) (parts []string, found []string) {
+	var (
+		quote byte
+		start int
+	)
+Loop:
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && quote != '\'':
+			i++ // Skip the escaped character.
+			continue Loop
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue Loop
+		case c == '\'' || c == '"':
+			quote = c
+			continue Loop
+		}
+		for _, op := range ops {
+			if strings.HasPrefix(line[i:], op) {
+				parts = append(parts, line[start:i])
+				found = append(found, op)
+				start = i + len(op)
+				i = start - 1
+				continue Loop
+			}
+		}
+	}
+	parts = append(parts, line[start:])
+	return
+}
+
+// chainStep is a command of a line joined by a chain operator.
+type chainStep struct {
+	op  string // Operator before the command, empty for the first one.
+	run func() error
+}
+
+// chainOps are the operators to chain commands, longest first.
+var chainOps = []string{"&&", "||", ";"}
+
+// execChain executes the steps sequentially. A step after '&&' only runs if
+// the previous one succeeded, a step after '||' only if it failed.
+// Errors of intermediate steps are printed, the last one is returned.
+func (a *App) execChain(steps []chainStep) error {
+	var err error
+	for _, s := range steps {
+		if a.IsClosing() {
+			break
+		}
+		if errors.Is(err, ErrInterruptExit) {
+			break
+		}
+		if (s.op == "&&" && err != nil) || (s.op == "||" && err == nil) {
+			continue
+		}
+		if err != nil {
+			a.PrintError(err)
+		}
+		err = s.run()
+	}
+	return err
+}
+
+// checkChain validates that no command between chain operators is empty.
+// A trailing ';' is allowed and reported by dropLast.
+func checkChain(empty []bool, ops []string) (dropLast bool, err error) {
+	for i, e := range empty {
+		if !e || len(ops) == 0 {
+			continue
+		}
+		if i == len(empty)-1 && ops[i-1] == ";" {
+			return true, nil
+		}
+		op := ops[len(ops)-1]
+		if i < len(ops) {
+			op = ops[i]
+		}
+		return false, fmt.Errorf("syntax error near unexpected token '%s'", op)
+	}
+	return false, nil
+}
+
+// splitChainLine splits a shell line at the chain operators.
+func splitChainLine(line string) (parts []string, ops []string, err error) {
+	parts, ops = splitUnquoted(line, chainOps...)
+	empty := make([]bool, len(parts))
+	for i, p := range parts {
+		empty[i] = len(strings.TrimSpace(p)) == 0
+	}
+	dropLast, err := checkChain(empty, ops)
+	if err != nil {
+		return nil, nil, err
+	} else if dropLast {
+		parts, ops = parts[:len(parts)-1], ops[:len(ops)-1]
+	}
+	return parts, ops, nil
+}
+
+// splitChainArgs splits command line args at the chain operators,
+// which must be passed as separate args, e.g. 'check && report'.
+func splitChainArgs(args []string) (groups [][]string, ops []string, err error) {
+	groups = [][]string{{}}
+	for _, arg := range args {
+		isOp := false
+		for _, op := range chainOps {
+			if arg == op {
+				isOp = true
+				break
+			}
+		}
+		if isOp {
+			ops = append(ops, arg)
+			groups = append(groups, []string{})
+			continue
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], arg)
+	}
+	empty := make([]bool, len(groups))
+	for i, g := range groups {
+		empty[i] = len(g) == 0
+	}
+	dropLast, err := checkChain(empty, ops)
+	if err != nil {
+		return nil, nil, err
+	} else if dropLast {
+		groups, ops = groups[:len(groups)-1], ops[:len(ops)-1]
+	}
+	return groups, ops, nil
+}
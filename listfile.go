@@ -0,0 +1,105 @@
+package jishell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	shlex "github.com/chroblert/go-shlex"
+)
+
+// Prefixes of list values, which are read from a file.
+const (
+	listFilePrefix    = "@"
+	listFileURLPrefix = "file:"
+)
+
+// NoListFile takes values of the form @path or file:path of a list flag or arg
+// literally, instead of reading the elements from the file.
+func NoListFile() ValueOption {
+	return func(r *valueRules) {
+		r.noListFile = true
+	}
+}
+
+// argListFile reports, whether the list arg reads @path values from files.
+func argListFile(opts []ArgOption) bool {
+	for _, o := range opts {
+		if vo, ok := o.(ValueOption); ok && noListFileOption(vo) {
+			return false
+		}
+	}
+	return true
+}
+
+// flagListFile reports, whether the list flag reads @path values from files.
+func flagListFile(opts []FlagOption) bool {
+	for _, o := range opts {
+		if vo, ok := o.(ValueOption); ok && noListFileOption(vo) {
+			return false
+		}
+	}
+	return true
+}
+
+func noListFileOption(o ValueOption) bool {
+	var r valueRules
+	o(&r)
+	return r.noListFile
+}
+
+// splitList splits a list value by comma. If fromFile is set, a value of the form
+// @path or file:path is read from the file instead, see readListFile. A leading @@
+// stands for a literal @. The path of the file is returned as well.
+func splitList(s string, fromFile bool) (elems []string, file string, err error) {
+	if fromFile {
+		var ok bool
+		file, ok = listFilePath(s)
+		if ok {
+			elems, err = readListFile(file)
+			return
+		} else if strings.HasPrefix(s, listFilePrefix+listFilePrefix) {
+			s = s[len(listFilePrefix):]
+		}
+	}
+	elems, err = shlex.Split(s, true, false, ',')
+	return
+}
+
+// listFilePath returns the path of a value of the form @path or file:path.
+func listFilePath(s string) (string, bool) {
+	switch {
+	case strings.HasPrefix(s, listFilePrefix+listFilePrefix):
+		return "", false
+	case strings.HasPrefix(s, listFilePrefix) && len(s) > len(listFilePrefix):
+		return s[len(listFilePrefix):], true
+	case strings.HasPrefix(s, listFileURLPrefix) && len(s) > len(listFileURLPrefix):
+		return s[len(listFileURLPrefix):], true
+	}
+	return "", false
+}
+
+// readListFile reads one list element per line.
+// Blank lines and lines starting with # are skipped.
+func readListFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list file: %v", err)
+	}
+	defer f.Close()
+
+	elems := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		elems = append(elems, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read list file '%s': %v", path, err)
+	}
+	return elems, nil
+}
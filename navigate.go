@@ -0,0 +1,114 @@
+package jishell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolveCommand resolves a command path relative to the current command.
+// Absolute paths start at the root, '..' is the parent, '.' the command itself
+// and '-' the previously used command. The root is returned as nil.
+func (a *App) resolveCommand(path string) (*Command, error) {
+	if path == "-" {
+		if !a.hasPreviousCmd {
+			return nil, fmt.Errorf("no previous command")
+		}
+		return a.previousCmd, nil
+	}
+	cmd := a.currentCmd
+	if strings.HasPrefix(path, "/") {
+		cmd = nil
+	}
+	for _, name := range strings.Split(path, "/") {
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			if cmd != nil {
+				cmd = cmd.parent
+			}
+			continue
+		}
+		next := a.childCommands(cmd).Get(name)
+		if next == nil || next.isBuiltin {
+			return nil, fmt.Errorf("command %s not found", path)
+		}
+		cmd = next
+	}
+	return cmd, nil
+}
+
+// childCommands returns the sub commands of cmd or the app commands for the root.
+func (a *App) childCommands(cmd *Command) *Commands {
+	if cmd == nil {
+		return &a.commands
+	}
+	return &cmd.commands
+}
+
+// switchCommand makes cmd the current command, nil switches to the root.
+// The prompt and the completer are updated.
+func (a *App) switchCommand(cmd *Command) error {
+	if cmd != nil {
+		// 初始化jflagMaps和jargMaps
+		err := cmd.initOptions()
+		if err != nil {
+			return err
+		}
+		// 未在本地设置的flag和arg使用全局值
+		// Global values, which do not fit a flag or arg, do not prevent the use of the command.
+		err = a.applyGlobals(cmd)
+		if err != nil {
+			a.Printf("warning: skipped global values:\n%v\n", err)
+		}
+	}
+	a.previousCmd = a.currentCmd
+	a.hasPreviousCmd = true
+	a.currentCmd = cmd
+
+	// 设置prompt
+	if cmd == nil {
+		a.currentPrompt = a.config.Name + " >> "
+	} else {
+		parentPath := "/"
+		if cmd.parent != nil {
+			parentPath = cmd.parent.fullPath() + "/"
+		}
+		a.currentPrompt = a.config.Name + " " + cmd.Name + "(" + parentPath + ") >> "
+	}
+	a.SetPrompt(a.currentPrompt)
+
+	// 设置自动填充参数
+	if a.rl != nil {
+		a.rl.Config.AutoComplete = newCompleter(a, a.childCommands(cmd), cmd)
+	}
+	return nil
+}
+
+// completeCommandPath returns the completions of a command path for use.
+// Commands with sub commands are completed with a trailing '/'.
+func (a *App) completeCommandPath(prefix string) (suggestions [][]rune, length int) {
+	dir, base := "", prefix
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir, base = prefix[:i+1], prefix[i+1:]
+	}
+	cmd := a.currentCmd
+	if len(dir) > 0 {
+		var err error
+		cmd, err = a.resolveCommand(dir)
+		if err != nil {
+			return nil, 0
+		}
+	}
+	for _, v := range a.childCommands(cmd).list {
+		if v.isBuiltin || !strings.HasPrefix(v.Name, base) {
+			continue
+		}
+		s := strings.TrimPrefix(v.Name, base)
+		if len(v.commands.list) > 0 {
+			s += "/"
+		}
+		suggestions = append(suggestions, []rune(s))
+	}
+	return suggestions, len(base)
+}
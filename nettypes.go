@@ -0,0 +1,191 @@
+package jishell
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxExpandBits limits the host bits of networks expanded by ExpandCIDR,
+// i.e. at most 2^20 addresses are returned.
+const maxExpandBits = 20
+
+// HostPort is a host with a port, e.g. example.com:443 or [::1]:80.
+type HostPort struct {
+	Host string
+	Port int
+}
+
+// String returns the host and port joined by a colon.
+func (h HostPort) String() string {
+	return net.JoinHostPort(h.Host, strconv.Itoa(h.Port))
+}
+
+// PortRange is a range of ports, e.g. 8000-8100. A single port has First equal to Last.
+type PortRange struct {
+	First int
+	Last  int
+}
+
+// String returns the range in the notation accepted by the parser.
+func (r PortRange) String() string {
+	if r.First == r.Last {
+		return strconv.Itoa(r.First)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// Ports returns all ports of the range.
+func (r PortRange) Ports() []int {
+	ports := make([]int, 0, r.Last-r.First+1)
+	for p := r.First; p <= r.Last; p++ {
+		ports = append(ports, p)
+	}
+	return ports
+}
+
+// ExpandPorts returns the sorted ports of all ranges without duplicates.
+func ExpandPorts(ranges []PortRange) []int {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, r := range ranges {
+		for p := r.First; p <= r.Last; p++ {
+			if !seen[p] {
+				seen[p] = true
+				ports = append(ports, p)
+			}
+		}
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// ExpandCIDR returns all addresses of the network including
+// the network and broadcast address.
+func ExpandCIDR(n *net.IPNet) ([]net.IP, error) {
+	ones, bits := n.Mask.Size()
+	if bits-ones > maxExpandBits {
+		return nil, fmt.Errorf("network %s has too many addresses to expand", n)
+	}
+	ip := n.IP.Mask(n.Mask)
+	if ip4 := ip.To4(); ip4 != nil && bits == 32 {
+		ip = ip4
+	}
+	count := 1 << uint(bits-ones)
+	ips := make([]net.IP, 0, count)
+	for i := 0; i < count; i++ {
+		ips = append(ips, append(net.IP(nil), ip...))
+		incIP(ip)
+	}
+	return ips, nil
+}
+
+// incIP increments the address by one.
+func incIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			return
+		}
+	}
+}
+
+// parseValueList parses the elements and appends them to a copy of list,
+// which is a typed slice, e.g. []net.IP.
+func parseValueList(list interface{}, elems []string, parse func(s string) (interface{}, error)) (interface{}, error) {
+	rv := reflect.ValueOf(list)
+	vs := reflect.MakeSlice(rv.Type(), 0, rv.Len()+len(elems))
+	vs = reflect.AppendSlice(vs, rv)
+	for _, e := range elems {
+		v, err := parse(e)
+		if err != nil {
+			return nil, err
+		}
+		vs = reflect.Append(vs, reflect.ValueOf(v))
+	}
+	return vs.Interface(), nil
+}
+
+func parseIP(s string) (interface{}, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address '%s'", s)
+	}
+	return ip, nil
+}
+
+// parseCIDR parses a network. A single address is a network of one host.
+func parseCIDR(s string) (interface{}, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid CIDR '%s'", s)
+		}
+		bits := 128
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR '%s'", s)
+	}
+	return n, nil
+}
+
+func parseHostPort(s string) (interface{}, error) {
+	host, portStr, err := net.SplitHostPort(s)
+	if err != nil || len(host) == 0 {
+		return nil, fmt.Errorf("invalid host:port '%s'", s)
+	}
+	port, err := parsePort(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid host:port '%s': %v", s, err)
+	}
+	return HostPort{Host: host, Port: port}, nil
+}
+
+func parsePortRange(s string) (interface{}, error) {
+	first, last := s, s
+	if pos := strings.Index(s, "-"); pos >= 0 {
+		first, last = s[:pos], s[pos+1:]
+	}
+	var (
+		r   PortRange
+		err error
+	)
+	if r.First, err = parsePort(first); err != nil {
+		return nil, fmt.Errorf("invalid port range '%s': %v", s, err)
+	}
+	if r.Last, err = parsePort(last); err != nil {
+		return nil, fmt.Errorf("invalid port range '%s': %v", s, err)
+	}
+	if r.First > r.Last {
+		return nil, fmt.Errorf("invalid port range '%s': %d is greater than %d", s, r.First, r.Last)
+	}
+	return r, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port '%s'", s)
+	}
+	return port, nil
+}
+
+// parseURL parses an absolute URL with scheme and host.
+func parseURL(s string) (interface{}, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL '%s'", s)
+	} else if len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return nil, fmt.Errorf("invalid URL '%s': missing scheme or host", s)
+	}
+	return u, nil
+}
@@ -0,0 +1,352 @@
+package jishell
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Struct tags of the command options.
+const (
+	flagTag     = "flag"     // short and long name, e.g. `flag:"t,target"` or `flag:"target"`
+	argTag      = "arg"      // name of the argument, e.g. `arg:"host"`
+	defaultTag  = "default"  // default value, lists are separated by comma
+	helpTag     = "help"     // help message
+	requiredTag = "required" // `required:"true"` marks a flag as required
+	envTag      = "env"      // environment variable of a flag
+)
+
+// optionKind registers the flags and args of a field type.
+// A nil func means, that the type is not supported as flag or arg.
+type optionKind struct {
+	flag func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption)
+	arg  func(a *Args, name, help string, opts ...ArgOption)
+}
+
+var optionKinds = map[reflect.Type]optionKind{
+	reflect.TypeOf(""): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.String(short, long, def.(string), help, opts...)
+		},
+		arg: (*Args).String,
+	},
+	reflect.TypeOf([]string{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.StringList(short, long, def.([]string), help, opts...)
+		},
+		arg: (*Args).StringList,
+	},
+	reflect.TypeOf(false): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.Bool(short, long, def.(bool), help, opts...)
+		},
+		arg: (*Args).Bool,
+	},
+	reflect.TypeOf([]bool{}): {arg: (*Args).BoolList},
+	reflect.TypeOf(0): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.Int(short, long, def.(int), help, opts...)
+		},
+		arg: (*Args).Int,
+	},
+	reflect.TypeOf([]int{}): {arg: (*Args).IntList},
+	reflect.TypeOf(int64(0)): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.Int64(short, long, def.(int64), help, opts...)
+		},
+		arg: (*Args).Int64,
+	},
+	reflect.TypeOf([]int64{}): {arg: (*Args).Int64List},
+	reflect.TypeOf(uint(0)): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.Uint(short, long, def.(uint), help, opts...)
+		},
+		arg: (*Args).Uint,
+	},
+	reflect.TypeOf([]uint{}): {arg: (*Args).UintList},
+	reflect.TypeOf(uint64(0)): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.Uint64(short, long, def.(uint64), help, opts...)
+		},
+		arg: (*Args).Uint64,
+	},
+	reflect.TypeOf([]uint64{}): {arg: (*Args).Uint64List},
+	reflect.TypeOf(float64(0)): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.Float64(short, long, def.(float64), help, opts...)
+		},
+		arg: (*Args).Float64,
+	},
+	reflect.TypeOf([]float64{}): {arg: (*Args).Float64List},
+	reflect.TypeOf(time.Duration(0)): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.Duration(short, long, def.(time.Duration), help, opts...)
+		},
+		arg: (*Args).Duration,
+	},
+	reflect.TypeOf([]time.Duration{}): {arg: (*Args).DurationList},
+	reflect.TypeOf(net.IP{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.IP(short, long, def.(net.IP), help, opts...)
+		},
+		arg: (*Args).IP,
+	},
+	reflect.TypeOf([]net.IP{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.IPList(short, long, def.([]net.IP), help, opts...)
+		},
+		arg: (*Args).IPList,
+	},
+	reflect.TypeOf(&net.IPNet{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.CIDR(short, long, def.(*net.IPNet), help, opts...)
+		},
+		arg: (*Args).CIDR,
+	},
+	reflect.TypeOf([]*net.IPNet{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.CIDRList(short, long, def.([]*net.IPNet), help, opts...)
+		},
+		arg: (*Args).CIDRList,
+	},
+	reflect.TypeOf(HostPort{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.HostPort(short, long, def.(HostPort), help, opts...)
+		},
+		arg: (*Args).HostPort,
+	},
+	reflect.TypeOf([]HostPort{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.HostPortList(short, long, def.([]HostPort), help, opts...)
+		},
+		arg: (*Args).HostPortList,
+	},
+	reflect.TypeOf(PortRange{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.PortRange(short, long, def.(PortRange), help, opts...)
+		},
+		arg: (*Args).PortRange,
+	},
+	reflect.TypeOf([]PortRange{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.PortRangeList(short, long, def.([]PortRange), help, opts...)
+		},
+		arg: (*Args).PortRangeList,
+	},
+	reflect.TypeOf(&url.URL{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.URL(short, long, def.(*url.URL), help, opts...)
+		},
+		arg: (*Args).URL,
+	},
+	reflect.TypeOf([]*url.URL{}): {
+		flag: func(f *Flags, short, long, help string, def interface{}, opts ...FlagOption) {
+			f.URLList(short, long, def.([]*url.URL), help, opts...)
+		},
+		arg: (*Args).URLList,
+	},
+}
+
+// registerOptions registers the flags and args defined by the tags of the struct fields.
+// Fields of embedded structs are included. Panics on invalid definitions.
+func registerOptions(f *Flags, a *Args, options interface{}) {
+	t := reflect.TypeOf(options)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Errorf("invalid options: %s is not a struct", t))
+	}
+	for _, field := range optionFields(t) {
+		flag, isFlag := field.Tag.Lookup(flagTag)
+		name, isArg := field.Tag.Lookup(argTag)
+		kind, ok := optionKinds[field.Type]
+		switch {
+		case isFlag && isArg:
+			panic(fmt.Errorf("invalid option '%s': both flag and arg", field.Name))
+		case isFlag && (!ok || kind.flag == nil):
+			panic(fmt.Errorf("invalid option '%s': type %s not supported for flags", field.Name, field.Type))
+		case isArg && (!ok || kind.arg == nil):
+			panic(fmt.Errorf("invalid option '%s': type %s not supported for args", field.Name, field.Type))
+		}
+
+		def, hasDefault := reflect.Zero(field.Type).Interface(), false
+		if s, ok := field.Tag.Lookup(defaultTag); ok {
+			v, err := parseOptionValue(field.Type, s)
+			if err != nil {
+				panic(fmt.Errorf("invalid default value of option '%s': %v", field.Name, err))
+			}
+			def, hasDefault = v, true
+		}
+		help := field.Tag.Get(helpTag)
+
+		if isFlag {
+			short, long := "", flag
+			if pos := strings.Index(flag, ","); pos >= 0 {
+				short, long = flag[:pos], flag[pos+1:]
+			}
+			var opts []FlagOption
+			if env, ok := field.Tag.Lookup(envTag); ok {
+				opts = append(opts, Env(env))
+			}
+			kind.flag(f, short, long, help, def, opts...)
+			if field.Tag.Get(requiredTag) == "true" {
+				f.Required(long)
+			}
+		} else if isArg {
+			var opts []ArgOption
+			if hasDefault {
+				opts = append(opts, Default(def))
+			}
+			kind.arg(a, name, help, opts...)
+		}
+	}
+}
+
+// optionFields returns the fields of the struct and its embedded structs.
+func optionFields(t reflect.Type) (fields []reflect.StructField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, optionFields(field.Type)...)
+		} else if len(field.PkgPath) == 0 {
+			fields = append(fields, field)
+		}
+	}
+	return
+}
+
+// parseOptionValue parses the string as value of the type.
+// List elements are separated by comma.
+func parseOptionValue(t reflect.Type, s string) (interface{}, error) {
+	if t.Kind() != reflect.Slice || t == reflect.TypeOf(net.IP{}) {
+		return parseOptionElem(t, s)
+	}
+	elems, _, err := splitList(s, false)
+	if err != nil {
+		return nil, err
+	}
+	return parseValueList(reflect.Zero(t).Interface(), elems, func(s string) (interface{}, error) {
+		return parseOptionElem(t.Elem(), s)
+	})
+}
+
+// parseOptionElem parses the string as single value of the type.
+func parseOptionElem(t reflect.Type, s string) (interface{}, error) {
+	switch t {
+	case reflect.TypeOf(time.Duration(0)):
+		return time.ParseDuration(s)
+	case reflect.TypeOf(net.IP{}):
+		return parseIP(s)
+	case reflect.TypeOf(&net.IPNet{}):
+		return parseCIDR(s)
+	case reflect.TypeOf(HostPort{}):
+		return parseHostPort(s)
+	case reflect.TypeOf(PortRange{}):
+		return parsePortRange(s)
+	case reflect.TypeOf(&url.URL{}):
+		return parseURL(s)
+	}
+
+	var (
+		v   interface{}
+		err error
+	)
+	switch t.Kind() {
+	case reflect.String:
+		v = s
+	case reflect.Bool:
+		v, err = strconv.ParseBool(s)
+	case reflect.Int, reflect.Int64:
+		v, err = strconv.ParseInt(s, 10, 64)
+	case reflect.Uint, reflect.Uint64:
+		v, err = strconv.ParseUint(s, 10, 64)
+	case reflect.Float64:
+		v, err = strconv.ParseFloat(s, 64)
+	default:
+		return nil, fmt.Errorf("type %s not supported", t)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value '%s'", t, s)
+	}
+	return reflect.ValueOf(v).Convert(t).Interface(), nil
+}
+
+// Bind sets the fields of the struct pointed to by v to the values of the flags and args
+// named by their tags, see Command.Options. Flags and args without a value are skipped.
+func (c *Context) Bind(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: %T is not a pointer to a struct", v)
+	}
+	return bindOptions(rv.Elem(), c.Flags, c.Args)
+}
+
+func bindOptions(sv reflect.Value, flags FlagMap, args ArgMap) error {
+	t := sv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			err := bindOptions(sv.Field(i), flags, args)
+			if err != nil {
+				return err
+			}
+			continue
+		} else if len(field.PkgPath) > 0 {
+			continue
+		}
+
+		var value interface{}
+		if flag, ok := field.Tag.Lookup(flagTag); ok {
+			long := flag[strings.Index(flag, ",")+1:]
+			item := flags[long]
+			if item == nil {
+				return fmt.Errorf("bind: flag '%s' not registered", long)
+			}
+			value = item.Value
+		} else if name, ok := field.Tag.Lookup(argTag); ok {
+			item := args[name]
+			if item == nil {
+				continue
+			}
+			value = item.Value
+		} else {
+			continue
+		}
+
+		err := assignValue(sv.Field(i), value)
+		if err != nil {
+			return fmt.Errorf("bind: field '%s': %v", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// assignValue sets the field to the value. The elements of lists, which are
+// stored as []interface{}, are assigned one by one.
+func assignValue(fv reflect.Value, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Type().AssignableTo(fv.Type()) {
+		fv.Set(rv)
+		return nil
+	}
+	if rv.Kind() != reflect.Slice || fv.Kind() != reflect.Slice {
+		return fmt.Errorf("cannot assign %T to %s", v, fv.Type())
+	}
+	list := reflect.MakeSlice(fv.Type(), rv.Len(), rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		err := assignValue(list.Index(i), rv.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+	}
+	fv.Set(list)
+	return nil
+}
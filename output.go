@@ -0,0 +1,407 @@
+package jishell
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats of the command results.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+	OutputYAML  = "yaml"
+	OutputText  = "text"
+)
+
+var outputFormats = []string{OutputTable, OutputJSON, OutputCSV, OutputYAML, OutputText}
+
+// Table is a result with named columns.
+type Table struct {
+	Header []string
+	Rows   [][]interface{}
+}
+
+// Emit writes the rows in the output format of the app. Each row holds
+// one value per column of the header.
+func (c *Context) Emit(header []string, rows [][]interface{}) error {
+	return c.Result(Table{Header: header, Rows: rows})
+}
+
+// Result writes the value in the output format of the app, which is set by
+// the --output flag or by 'set output' in the shell. Slices of structs or maps,
+// maps and structs are written as tables, other values as they are.
+// In direct mode, the --query flag selects a part of the JSON form of the value.
+// The results of all commands but the builtin ones are kept for $results[n].
+// Errors while keeping the result are printed, the result is written anyway.
+func (c *Context) Result(v interface{}) error {
+	if c.Command != nil && !c.Command.isBuiltin {
+		err := c.App.recordResult(c.Command, v)
+		if err != nil {
+			c.App.PrintError(fmt.Errorf("failed to keep the result: %v", err))
+		}
+	}
+	w := c.Stdout()
+	if _, ok := w.(*queryBuffer); ok {
+		return writeResult(w, OutputJSON, v)
+	}
+	if len(c.App.query) > 0 && !c.App.isShell {
+		data, err := resultJSON(v)
+		if err != nil {
+			return err
+		}
+		return c.App.writeQueryResult(w, gjson.GetBytes(data, c.App.query))
+	}
+	return writeResult(w, c.App.Output(), v)
+}
+
+// Output returns the output format of the command results.
+func (a *App) Output() string {
+	if len(a.output) == 0 {
+		return OutputTable
+	}
+	return a.output
+}
+
+// SetOutput sets the output format of the command results.
+func (a *App) SetOutput(format string) error {
+	for _, f := range outputFormats {
+		if f == format {
+			a.output = format
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output format '%s': must be one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+// writeResult writes the value in the format.
+func writeResult(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case OutputJSON, OutputYAML:
+		data, err := resultJSON(v)
+		if err != nil {
+			return err
+		}
+		if format == OutputYAML {
+			data, err = jsonToYAML(data)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		}
+		var buf bytes.Buffer
+		err = json.Indent(&buf, data, "", "  ")
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err = buf.WriteTo(w)
+		return err
+	}
+
+	// JSON results, e.g. of the query filter, are decoded to be written as table.
+	if raw, ok := v.(json.RawMessage); ok {
+		var dv interface{}
+		err := json.Unmarshal(raw, &dv)
+		if err != nil {
+			return err
+		}
+		v = dv
+	}
+	t, isTable := toTable(v)
+	switch format {
+	case OutputCSV:
+		if !isTable {
+			t = Table{Header: []string{"Value"}, Rows: [][]interface{}{{v}}}
+		}
+		cw := csv.NewWriter(w)
+		err := cw.Write(t.Header)
+		if err != nil {
+			return err
+		}
+		for _, row := range t.Rows {
+			err = cw.Write(t.strings(row))
+			if err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case OutputText:
+		if !isTable {
+			_, err := fmt.Fprintln(w, displayValue(v))
+			return err
+		}
+		for _, row := range t.Rows {
+			_, err := fmt.Fprintln(w, strings.Join(t.strings(row), "\t"))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		if !isTable {
+			_, err := fmt.Fprintln(w, displayValue(v))
+			return err
+		}
+		tw := table.NewWriter()
+		header := make(table.Row, len(t.Header))
+		for i, h := range t.Header {
+			header[i] = h
+		}
+		tw.AppendHeader(header)
+		for _, row := range t.Rows {
+			cells := make(table.Row, len(t.Header))
+			for i, s := range t.strings(row) {
+				cells[i] = s
+			}
+			tw.AppendRow(cells)
+		}
+		_, err := fmt.Fprintln(w, tw.Render())
+		return err
+	}
+}
+
+// strings formats the values of the row.
+func (t Table) strings(row []interface{}) []string {
+	s := make([]string, len(t.Header))
+	for i := range s {
+		if i < len(row) {
+			s[i] = displayValue(row[i])
+		}
+	}
+	return s
+}
+
+// objects returns the rows as JSON objects, which keep the order of the columns.
+func (t Table) objects() []json.RawMessage {
+	objs := make([]json.RawMessage, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		var b strings.Builder
+		b.WriteString("{")
+		for i, h := range t.Header {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			key, _ := json.Marshal(h)
+			var v interface{}
+			if i < len(row) {
+				v = jsonValue(row[i])
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				value, _ = json.Marshal(fmt.Sprintf("%v", v))
+			}
+			b.Write(key)
+			b.WriteString(":")
+			b.Write(value)
+		}
+		b.WriteString("}")
+		objs = append(objs, json.RawMessage(b.String()))
+	}
+	return objs
+}
+
+// jsonValue returns the string form of values such as net.IP,
+// which would otherwise be encoded as bytes or structs.
+func jsonValue(v interface{}) interface{} {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return v
+}
+
+// jsonToYAML converts JSON to YAML, keeping the order of the object keys.
+func jsonToYAML(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(v)
+}
+
+// decodeOrdered decodes the next JSON value. Objects are decoded to yaml.MapSlice.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := yaml.MapSlice{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, yaml.MapItem{Key: key, Value: value})
+		}
+		_, err = dec.Token()
+		return m, err
+	case json.Delim('['):
+		l := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, value)
+		}
+		_, err = dec.Token()
+		return l, err
+	}
+	if n, ok := tok.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		return n.Float64()
+	}
+	return tok, nil
+}
+
+// toTable converts a tabular value to a table.
+func toTable(v interface{}) (Table, bool) {
+	switch t := v.(type) {
+	case Table:
+		return t, true
+	case *Table:
+		return *t, true
+	case nil:
+		return Table{}, false
+	}
+	if _, ok := v.(fmt.Stringer); ok {
+		return Table{}, false
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		header, row := structRow(rv)
+		return Table{Header: header, Rows: [][]interface{}{row}}, true
+	case reflect.Map:
+		t := Table{Header: []string{"Key", "Value"}}
+		for _, k := range sortedMapKeys(rv) {
+			t.Rows = append(t.Rows, []interface{}{k.Interface(), rv.MapIndex(k).Interface()})
+		}
+		return t, true
+	case reflect.Slice, reflect.Array:
+	default:
+		return Table{}, false
+	}
+
+	// Lists of structs, maps or values.
+	elem := rv.Type().Elem()
+	if elem.Kind() == reflect.Interface {
+		// Decoded JSON lists are tables, if all elements are objects.
+		maps := make([]map[string]interface{}, rv.Len())
+		for i := range maps {
+			m, ok := rv.Index(i).Interface().(map[string]interface{})
+			if !ok {
+				maps = nil
+				break
+			}
+			maps[i] = m
+		}
+		if len(maps) > 0 {
+			return toTable(maps)
+		}
+	}
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	t := Table{}
+	switch elem.Kind() {
+	case reflect.Struct:
+		if _, ok := reflect.Zero(elem).Interface().(fmt.Stringer); ok {
+			break
+		}
+		for i := 0; i < rv.Len(); i++ {
+			ev := reflect.Indirect(rv.Index(i))
+			if !ev.IsValid() {
+				continue
+			}
+			var row []interface{}
+			t.Header, row = structRow(ev)
+			t.Rows = append(t.Rows, row)
+		}
+		return t, true
+	case reflect.Map:
+		// The columns are the keys of all maps.
+		seen := make(map[string]bool)
+		for i := 0; i < rv.Len(); i++ {
+			for _, k := range sortedMapKeys(rv.Index(i)) {
+				key := fmt.Sprintf("%v", k.Interface())
+				if !seen[key] {
+					seen[key] = true
+					t.Header = append(t.Header, key)
+				}
+			}
+		}
+		sort.Strings(t.Header)
+		for i := 0; i < rv.Len(); i++ {
+			m := rv.Index(i)
+			row := make([]interface{}, len(t.Header))
+			for _, k := range m.MapKeys() {
+				key := fmt.Sprintf("%v", k.Interface())
+				row[sort.SearchStrings(t.Header, key)] = m.MapIndex(k).Interface()
+			}
+			t.Rows = append(t.Rows, row)
+		}
+		return t, true
+	}
+	t.Header = []string{"Value"}
+	for i := 0; i < rv.Len(); i++ {
+		t.Rows = append(t.Rows, []interface{}{rv.Index(i).Interface()})
+	}
+	return t, true
+}
+
+// structRow returns the exported fields of the struct as columns.
+// The column names are taken from the json tags if present.
+func structRow(sv reflect.Value) (header []string, row []interface{}) {
+	t := sv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if len(tag) > 0 {
+			name = tag
+		}
+		header = append(header, name)
+		row = append(row, sv.Field(i).Interface())
+	}
+	return
+}
+
+// sortedMapKeys returns the keys of the map sorted by their string form.
+func sortedMapKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprintf("%v", keys[i].Interface()) < fmt.Sprintf("%v", keys[j].Interface())
+	})
+	return keys
+}
@@ -0,0 +1,152 @@
+package jishell
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	shlex "github.com/chroblert/go-shlex"
+)
+
+// Kinds of path values. Files and directories must be of the kind, if they exist.
+const (
+	pathAny = iota + 1
+	pathFile
+	pathDir
+)
+
+// MustExist requires the path to exist.
+func MustExist() ValueOption {
+	return func(r *valueRules) {
+		r.pathExist = 1
+	}
+}
+
+// MustNotExist requires the path not to exist, e.g. for output files.
+func MustNotExist() ValueOption {
+	return func(r *valueRules) {
+		r.pathExist = -1
+	}
+}
+
+// pathKind marks the value as path, which is completed from the filesystem.
+func pathKind(kind int) ValueOption {
+	return func(r *valueRules) {
+		r.pathKind = kind
+	}
+}
+
+// parsePath expands a leading ~ to the home directory.
+func parsePath(s string) (interface{}, error) {
+	return expandHome(s), nil
+}
+
+// parseFlagPath removes the quotes of flag values passed on the command line,
+// before the path is checked. Values of setf, which are not a single quoted word, are kept.
+func parseFlagPath(s string) (interface{}, error) {
+	if w, err := shlex.Split(s, true, false); err == nil && len(w) == 1 {
+		s = w[0]
+	}
+	return parsePath(s)
+}
+
+// isPath returns true, if the flag is a path flag, whose value is unquoted by its parser.
+func (f *Flags) isPath(long string) bool {
+	for _, i := range f.list {
+		if i.Long == long {
+			return i.rules.pathKind != 0
+		}
+	}
+	return false
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// checkPath validates the existence and the kind of the path.
+func (r *valueRules) checkPath(name, path string) error {
+	if len(path) == 0 {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		if r.pathExist > 0 {
+			return fmt.Errorf("invalid value '%s' for %s: does not exist", path, name)
+		}
+	case err != nil:
+		return fmt.Errorf("invalid value '%s' for %s: %v", path, name, err)
+	case r.pathExist < 0:
+		return fmt.Errorf("invalid value '%s' for %s: already exists", path, name)
+	case r.pathKind == pathFile && fi.IsDir():
+		return fmt.Errorf("invalid value '%s' for %s: is a directory", path, name)
+	case r.pathKind == pathDir && !fi.IsDir():
+		return fmt.Errorf("invalid value '%s' for %s: is not a directory", path, name)
+	}
+	return nil
+}
+
+// completePath returns the entries of the directory of the prefix, which start with the prefix.
+// Directories end with a slash. Hidden entries are only returned if the prefix starts with a dot.
+func completePath(prefix string, dirsOnly bool) []string {
+	if prefix == "~" {
+		return []string{"~/"}
+	}
+	dir, base := filepath.Split(prefix)
+	readDir := expandHome(dir)
+	if len(readDir) == 0 {
+		readDir = "."
+	}
+	entries, err := ioutil.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		isDir := e.IsDir()
+		if e.Mode()&os.ModeSymlink != 0 {
+			if fi, err := os.Stat(filepath.Join(readDir, name)); err == nil {
+				isDir = fi.IsDir()
+			}
+		}
+		if isDir {
+			name += "/"
+		} else if dirsOnly {
+			continue
+		}
+		paths = append(paths, dir+name)
+	}
+	return paths
+}
+
+// escapeCompletion quotes the completed text by the shlex rules,
+// either inside the open quote or by quoting the text as extra part of the word.
+func escapeCompletion(s, quote string) string {
+	switch {
+	case quote == "'":
+		return strings.ReplaceAll(s, "'", `'\''`)
+	case quote == `"`:
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		return strings.ReplaceAll(s, `"`, `\"`)
+	case !strings.ContainsAny(s, " \t'\"\\"):
+		return s
+	case !strings.Contains(s, "'"):
+		return "'" + s + "'"
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
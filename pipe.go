@@ -0,0 +1,329 @@
+package jishell
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	shlex "github.com/chroblert/go-shlex"
+)
+
+// pipeFilter processes the output of the previous command in a pipe.
+type pipeFilter func(args []string, in io.Reader, out io.Writer) error
+
+// pipeFilters are the builtin filters, which can be used after a pipe operator.
+// They take precedence over commands with the same name.
+var pipeFilters = map[string]pipeFilter{
+	"grep": filterGrep,
+	"head": filterHead,
+	"tail": filterTail,
+	"wc":   filterWc,
+}
+
+// execPipeline executes a single shell line, which may contain pipes
+// and an output redirection, e.g. 'scan | grep 443 > out.txt'.
+// The first command reads stdin and the last one writes to stdout, if not redirected.
+// The variables are expanded after the operators have been split, so that
+// their values can not add pipes, redirections or background jobs.
+func (a *App) execPipeline(line string, stdin io.Reader, stdout io.Writer) error {
+	stages, _ := splitUnquoted(line, "|")
+
+	// Parse the output redirection, which is only allowed at the end.
+	var (
+		redirect   string
+		appendMode bool
+	)
+	for i, stage := range stages {
+		parts, ops := splitUnquoted(stage, ">>", ">")
+		if len(ops) == 0 {
+			continue
+		}
+		if i != len(stages)-1 || len(ops) > 1 {
+			return fmt.Errorf("output redirection is only allowed once at the end of the line")
+		}
+		target, err := a.expandVars(strings.TrimSpace(parts[1]))
+		if err == nil {
+			target, err = unquoteValue(target)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid args: %v", err)
+		} else if len(target) == 0 {
+			return fmt.Errorf("missing file for output redirection")
+		}
+		stages[i] = parts[0]
+		redirect = target
+		appendMode = ops[0] == ">>"
+	}
+
+	// A trailing '&' runs the command as background job.
+	last := len(stages) - 1
+	background := false
+	if args, err := shlex.Split(stages[last], true, true); err == nil && len(args) > 1 && args[len(args)-1] == "&" {
+		background = true
+		stages[last] = strings.TrimSuffix(strings.TrimSpace(stages[last]), "&")
+	}
+
+	// Expand the variables right before the execution, so that $? refers to
+	// the previous command, and split the stages to args.
+	stageArgs := make([][]string, len(stages))
+	for i, stage := range stages {
+		expanded, err := a.expandVars(stage)
+		if err != nil {
+			return err
+		}
+		args, err := shlex.Split(expanded, true, true)
+		if err != nil {
+			return fmt.Errorf("invalid args: %v", err)
+		} else if len(args) == 0 && len(stages) > 1 {
+			return fmt.Errorf("invalid pipe: empty command")
+		}
+		stageArgs[i] = args
+	}
+	// Remember the last argument for $_.
+	if last := stageArgs[len(stageArgs)-1]; len(last) > 0 {
+		defer func() {
+			a.lastArg = unquoteArgs(last[len(last)-1:])[0]
+		}()
+	}
+
+	if background {
+		if len(stages) > 1 || len(redirect) > 0 {
+			return fmt.Errorf("background jobs can not be combined with pipes or redirection")
+		}
+		args := stageArgs[last]
+		if len(args) == 0 {
+			return fmt.Errorf("invalid args: missing command before '&'")
+		}
+		if _, ok := a.alias(args[0]); ok {
+			return fmt.Errorf("aliases can not run as background jobs")
+		}
+		ctx, err := a.commandContext(args)
+		if err == nil && ctx != nil {
+			a.startJob(ctx)
+		}
+		return err
+	}
+
+	out := stdout
+	if len(redirect) > 0 {
+		flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if appendMode {
+			flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		f, err := os.OpenFile(redirect, flag, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	// Run the stages and pass the output of each stage to the next one.
+	in := stdin
+	for i, args := range stageArgs {
+		stageOut := out
+		var buf io.ReadWriter
+		if i < len(stageArgs)-1 {
+			// The results of commands piped to the query filter are JSON.
+			if firstArg(stageArgs[i+1]) == queryFilter {
+				buf = &queryBuffer{}
+			} else {
+				buf = &bytes.Buffer{}
+			}
+			stageOut = buf
+		}
+
+		var err error
+		// Filters also read the input of the pipe an alias is part of.
+		piped := i > 0 || in != nil
+		if firstArg(args) == queryFilter && piped {
+			if stageOut == nil {
+				stageOut = a
+			}
+			err = a.filterQuery(unquoteArgs(args[1:]), in, stageOut)
+		} else if filter, ok := pipeFilters[firstArg(args)]; ok && piped {
+			if stageOut == nil {
+				stageOut = a
+			}
+			err = filter(unquoteArgs(args[1:]), in, stageOut)
+		} else {
+			err = a.runCommand(args, in, stageOut)
+		}
+		if err != nil {
+			return err
+		}
+		if buf != nil {
+			in = buf
+		}
+	}
+	return nil
+}
+
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// unquoteArgs removes the shell quotes of all args.
+func unquoteArgs(args []string) []string {
+	res := make([]string, len(args))
+	for i, arg := range args {
+		v, err := unquoteValue(arg)
+		if err != nil {
+			v = arg
+		}
+		res[i] = v
+	}
+	return res
+}
+
+// filterGrep prints the lines matching the regular expression.
+func filterGrep(args []string, in io.Reader, out io.Writer) error {
+	var f Flags
+	f.Bool("i", "ignore-case", false, "ignore case distinctions")
+	f.Bool("v", "invert-match", false, "select non-matching lines")
+	res := make(FlagMap)
+	args, err := f.parse(args, res)
+	if err != nil {
+		return fmt.Errorf("grep: %v", err)
+	} else if len(args) != 1 {
+		return fmt.Errorf("usage: grep [-i] [-v] pattern")
+	}
+	pattern := args[0]
+	if res.Bool("ignore-case") {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("grep: %v", err)
+	}
+	invert := res.Bool("invert-match")
+	return scanLines(in, func(line string) error {
+		if re.MatchString(line) != invert {
+			_, err := fmt.Fprintln(out, line)
+			return err
+		}
+		return nil
+	})
+}
+
+// filterHead prints the first lines.
+func filterHead(args []string, in io.Reader, out io.Writer) error {
+	n, err := parseLineCount("head", args)
+	if err != nil {
+		return err
+	}
+	count := 0
+	return scanLines(in, func(line string) error {
+		if count >= n {
+			return nil
+		}
+		count++
+		_, err := fmt.Fprintln(out, line)
+		return err
+	})
+}
+
+// filterTail prints the last lines.
+func filterTail(args []string, in io.Reader, out io.Writer) error {
+	n, err := parseLineCount("tail", args)
+	if err != nil {
+		return err
+	}
+	var lines []string
+	err = scanLines(in, func(line string) error {
+		lines = append(lines, line)
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		_, err = fmt.Fprintln(out, line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterWc prints the number of lines, words and bytes.
+func filterWc(args []string, in io.Reader, out io.Writer) error {
+	var f Flags
+	f.Bool("l", "lines", false, "print the line count")
+	f.Bool("w", "words", false, "print the word count")
+	f.Bool("c", "bytes", false, "print the byte count")
+	res := make(FlagMap)
+	args, err := f.parse(args, res)
+	if err != nil {
+		return fmt.Errorf("wc: %v", err)
+	} else if len(args) > 0 {
+		return fmt.Errorf("usage: wc [-l] [-w] [-c]")
+	}
+	data, err := readAll(in)
+	if err != nil {
+		return err
+	}
+	lines := bytes.Count(data, []byte("\n"))
+	words := len(strings.Fields(string(data)))
+
+	var counts []string
+	all := !res.Bool("lines") && !res.Bool("words") && !res.Bool("bytes")
+	if all || res.Bool("lines") {
+		counts = append(counts, fmt.Sprint(lines))
+	}
+	if all || res.Bool("words") {
+		counts = append(counts, fmt.Sprint(words))
+	}
+	if all || res.Bool("bytes") {
+		counts = append(counts, fmt.Sprint(len(data)))
+	}
+	_, err = fmt.Fprintln(out, strings.Join(counts, " "))
+	return err
+}
+
+func parseLineCount(name string, args []string) (int, error) {
+	var f Flags
+	f.Int("n", "lines", 10, "number of lines")
+	res := make(FlagMap)
+	args, err := f.parse(args, res)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", name, err)
+	} else if len(args) > 0 || res.Int("lines") < 0 {
+		return 0, fmt.Errorf("usage: %s [-n lines]", name)
+	}
+	return res.Int("lines"), nil
+}
+
+func scanLines(in io.Reader, fn func(line string) error) error {
+	if in == nil {
+		return nil
+	}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		err := fn(scanner.Text())
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func readAll(in io.Reader) ([]byte, error) {
+	if in == nil {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	_, err := buf.ReadFrom(in)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,59 @@
+package jishell
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/tidwall/gjson"
+)
+
+// queryFilter is the pipe filter, which selects parts of the JSON
+// results of the previous command by a gjson path.
+const queryFilter = "query"
+
+// queryBuffer is the output of a command piped to the query filter.
+// Results written to it are always JSON.
+type queryBuffer struct {
+	bytes.Buffer
+}
+
+// filterQuery prints the part of the JSON input selected by the gjson path,
+// e.g. 'scan | query hosts.#(port==443).ip'. Nothing is printed if the path does not match.
+func (a *App) filterQuery(args []string, in io.Reader, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: query path")
+	}
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	} else if !gjson.ValidBytes(data) {
+		return fmt.Errorf("query: the input is not JSON, commands must write their results with Emit or Result")
+	}
+	return a.writeQueryResult(out, gjson.GetBytes(data, args[0]))
+}
+
+// writeQueryResult writes the matched value in the output format of the app.
+func (a *App) writeQueryResult(w io.Writer, res gjson.Result) error {
+	if !res.Exists() {
+		return nil
+	}
+	format := a.Output()
+	if _, ok := w.(*queryBuffer); ok {
+		format = OutputJSON
+	}
+	return writeResult(w, format, json.RawMessage(res.Raw))
+}
+
+// resultJSON returns the JSON form of the result. Tables are lists of objects.
+func resultJSON(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case Table:
+		return json.Marshal(t.objects())
+	case *Table:
+		return json.Marshal(t.objects())
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,83 @@
+package jishell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxResourceDepth limits nested resource commands.
+const maxResourceDepth = 16
+
+// RunResource executes the lines of the resource file as if they were typed
+// in the shell. Empty lines and lines starting with '#' are skipped and a
+// trailing backslash continues the line. Each line is echoed before it is executed.
+// If continueOnError is false, the execution stops at the first failing line.
+func (a *App) RunResource(path string, continueOnError bool) error {
+	if a.resourceDepth >= maxResourceDepth {
+		return fmt.Errorf("resource %s: nested too deep", path)
+	}
+	a.resourceDepth++
+	defer func() { a.resourceDepth-- }()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// exec echoes and executes a complete line.
+	exec := func(line string, lineNum int) error {
+		if len(line) == 0 || a.IsClosing() {
+			return nil
+		}
+		a.Printf("%s%s\n", a.currentPrompt, line)
+		err := a.execLine(line)
+		if err == nil {
+			return nil
+		}
+		if !continueOnError || errors.Is(err, ErrInterruptExit) {
+			return fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		a.PrintError(err)
+		return nil
+	}
+
+	var (
+		lines   []string
+		lineNum int
+		start   int
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() && !a.IsClosing() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if len(lines) == 0 {
+			start = lineNum
+			if len(line) == 0 || strings.HasPrefix(line, "#") {
+				continue
+			}
+		}
+
+		// Handle multiline input.
+		if strings.HasSuffix(line, "\\") {
+			lines = append(lines, strings.TrimSpace(line[:len(line)-1]))
+			continue
+		}
+		lines = append(lines, line)
+		line = strings.TrimSpace(strings.Join(lines, ""))
+		lines = lines[:0]
+
+		err = exec(line, start)
+		if err != nil {
+			return err
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	// Execute a dangling continued line.
+	return exec(strings.TrimSpace(strings.Join(lines, "")), start)
+}
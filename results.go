@@ -0,0 +1,219 @@
+package jishell
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// resultEntry is a result written by a command with Emit or Result.
+type resultEntry struct {
+	Index   int             `json:"index"`
+	Time    time.Time       `json:"time"`
+	Command string          `json:"command"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// resultStore keeps the latest results. Background jobs add results concurrently.
+type resultStore struct {
+	mu      sync.Mutex
+	entries []resultEntry
+	next    int
+}
+
+// add stores the result and drops the oldest results above the limit.
+func (s *resultStore) add(e resultEntry, limit int) resultEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.Index == 0 {
+		s.next++
+		e.Index = s.next
+	} else if e.Index > s.next {
+		s.next = e.Index
+	}
+	s.entries = append(s.entries, e)
+	if limit > 0 && len(s.entries) > limit {
+		s.entries = s.entries[len(s.entries)-limit:]
+	}
+	return e
+}
+
+// get returns the result with the index. Negative indexes count from the
+// latest result, e.g. -1 is the last one.
+func (s *resultStore) get(index int) (resultEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index < 0 {
+		if -index > len(s.entries) {
+			return resultEntry{}, false
+		}
+		return s.entries[len(s.entries)+index], true
+	}
+	for _, e := range s.entries {
+		if e.Index == index {
+			return e, true
+		}
+	}
+	return resultEntry{}, false
+}
+
+// list returns a copy of all results.
+func (s *resultStore) list() []resultEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]resultEntry(nil), s.entries...)
+}
+
+func (s *resultStore) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+}
+
+// recordResult stores the JSON form of the result of the command
+// and appends it to the result file, if configured.
+func (a *App) recordResult(cmd *Command, v interface{}) error {
+	if a.config.ResultLimit < 0 {
+		return nil
+	}
+	data, err := resultJSON(v)
+	if err != nil {
+		return err
+	}
+	e := a.results.add(resultEntry{Time: time.Now(), Command: cmd.fullPath(), Data: data}, a.config.ResultLimit)
+	if len(a.config.ResultFile) == 0 || !a.isShell {
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(a.config.ResultFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// clearResults removes all results and truncates the result file, if configured,
+// so that they are not restored on the next start.
+func (a *App) clearResults() error {
+	a.results.clear()
+	if len(a.config.ResultFile) == 0 || !a.isShell {
+		return nil
+	}
+	err := os.Truncate(a.config.ResultFile, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// loadResults restores the results of the result file configured in the config.
+// The file is truncated to the result limit.
+func (a *App) loadResults() error {
+	if len(a.config.ResultFile) == 0 || a.config.ResultLimit < 0 {
+		return nil
+	}
+	f, err := os.Open(a.config.ResultFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		var e resultEntry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		a.results.add(e, a.config.ResultLimit)
+		lines = append(lines, scanner.Text())
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	// Keep the file small.
+	if a.config.ResultLimit > 0 && len(lines) > a.config.ResultLimit {
+		lines = lines[len(lines)-a.config.ResultLimit:]
+		return ioutil.WriteFile(a.config.ResultFile, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	}
+	return nil
+}
+
+// resultValue returns the value of a result reference, e.g. 'results[3].hosts.#.ip'.
+// Lists are separated by comma, so that they can be passed to list flags and args.
+func (a *App) resultValue(ref string) (string, error) {
+	end := strings.IndexByte(ref, ']')
+	if !strings.HasPrefix(ref, "results[") || end < 0 {
+		return "", fmt.Errorf("invalid result reference: %s", ref)
+	}
+	index, err := strconv.Atoi(ref[len("results["):end])
+	if err != nil {
+		return "", fmt.Errorf("invalid result reference: %s", ref)
+	}
+	e, ok := a.results.get(index)
+	if !ok {
+		return "", fmt.Errorf("result %d does not exist", index)
+	}
+	path := strings.TrimPrefix(ref[end+1:], ".")
+	res := gjson.ParseBytes(e.Data)
+	if len(path) > 0 {
+		res = res.Get(path)
+	}
+	if !res.Exists() {
+		return "", fmt.Errorf("result %d has no value for '%s'", index, path)
+	}
+	if !res.IsArray() {
+		return resultString(res), nil
+	}
+	var elems []string
+	for _, r := range res.Array() {
+		elems = append(elems, quoteListElem(resultString(r)))
+	}
+	return strings.Join(elems, ","), nil
+}
+
+// resultString returns strings without quotes and other values as JSON.
+func resultString(res gjson.Result) string {
+	if res.Type == gjson.String {
+		return res.String()
+	}
+	return res.Raw
+}
+
+// quoteResultValue quotes the value for the position in the line, which is
+// inside double quotes or unquoted.
+func quoteResultValue(value string, quote byte) string {
+	if quote == '"' {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		return strings.ReplaceAll(value, `"`, `\"`)
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// isResultRef returns true, if the variable name at the position starts a result reference.
+func isResultRef(line string, i int) bool {
+	return strings.HasPrefix(line[i:], "results[")
+}
+
+// isResultPathChar returns true for the characters of a result path without braces.
+func isResultPathChar(c byte) bool {
+	return c == '.' || c == '#' || c == '*' || c == '?' || c == '-' || c == '_' ||
+		c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,171 @@
+package jishell
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Option filters of the show command.
+const (
+	showAllOptions     = "options"
+	showMissingOptions = "missing"
+	showChangedOptions = "changed"
+)
+
+// showOptions prints the flags and args of the command matching the filter.
+func showOptions(c *Context, cmd *Command, filter string) {
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Name", "Value", "Default", "Source", "Type", "Kind", "Description"})
+	t.AppendRows(flagRows(cmd, filter))
+	t.AppendSeparator()
+	t.AppendRows(argRows(cmd, filter))
+	c.Println(t.Render())
+}
+
+// flagRows returns the table rows of the flags matching the filter.
+func flagRows(cmd *Command, filter string) (rows []table.Row) {
+	for _, v := range cmd.flags.list {
+		// JC 220514: 过滤掉help flag
+		if v.Long == "help" {
+			continue
+		}
+		item, ok := cmd.jflagMaps[v.Long]
+		set := ok && !item.overridable()
+		switch {
+		case filter == showMissingOptions && (set || !v.required):
+			continue
+		case filter == showChangedOptions && !set:
+			continue
+		}
+		value, source := "", ""
+		if ok {
+			value, source = displayItem(item.Value, item.file), item.Source
+		}
+		rows = append(rows, table.Row{v.Long, value, displayValue(v.Default), source, optionType(v.HelpArgs), "flag", v.Help})
+	}
+	return
+}
+
+// argRows returns the table rows of the args matching the filter.
+func argRows(cmd *Command, filter string) (rows []table.Row) {
+	for _, v := range cmd.args.list {
+		item, ok := cmd.jargMaps[v.Name]
+		switch {
+		case filter == showMissingOptions && (ok || v.optional):
+			continue
+		case filter == showChangedOptions && (!ok || item.IsDefault):
+			continue
+		}
+		value, source := "", ""
+		if ok {
+			value, source = displayItem(item.Value, item.file), SourceSet
+			if item.IsDefault {
+				source = SourceDefault
+			} else if cmd.globalOpts[v.Name] {
+				source = SourceGlobal
+			}
+		}
+		rows = append(rows, table.Row{v.Name, value, displayValue(v.Default), source, optionType(v.HelpArgs), "arg", v.Help})
+	}
+	return
+}
+
+// missingOptions returns the names of the required flags and args, which have not been set.
+func (c *Command) missingOptions() (names []string) {
+	names = c.flags.missing(c.jflagMaps)
+	for _, v := range c.args.list {
+		if _, ok := c.jargMaps[v.Name]; !ok && !v.optional {
+			names = append(names, v.Name)
+		}
+	}
+	return
+}
+
+// runArgs returns the args to run the command with the option state.
+// Optional args, which have not been set, take their default value.
+func (c *Command) runArgs() ArgMap {
+	args := c.jargMaps.clone()
+	for _, v := range c.args.list {
+		if _, ok := args[v.Name]; !ok && v.optional {
+			args[v.Name] = &ArgMapItem{Value: v.Default, IsDefault: true}
+		}
+	}
+	return args
+}
+
+// showInfo prints the description of the command.
+func showInfo(c *Context, cmd *Command) {
+	var subs []string
+	for _, v := range cmd.commands.list {
+		subs = append(subs, v.Name)
+	}
+	t := table.NewWriter()
+	t.AppendRows([]table.Row{
+		{"Name", cmd.Name},
+		{"Path", cmd.fullPath()},
+		{"Aliases", strings.Join(cmd.Aliases, ", ")},
+		{"Help", cmd.Help},
+		{"Description", cmd.LongHelp},
+		{"Usage", cmd.Usage},
+		{"Sub Commands", strings.Join(subs, ", ")},
+	})
+	c.Println(t.Render())
+}
+
+// showAll prints the changed options of every command that has been used.
+func showAll(c *Context) {
+	c.App.commands.walk(func(cmd *Command) {
+		if cmd.isBuiltin || cmd.jflagMaps == nil {
+			return
+		}
+		flags := flagRows(cmd, showChangedOptions)
+		args := argRows(cmd, showChangedOptions)
+		if len(flags) == 0 && len(args) == 0 {
+			return
+		}
+		t := table.NewWriter()
+		t.SetTitle(cmd.fullPath())
+		t.AppendHeader(table.Row{"Name", "Value", "Default", "Source", "Type", "Kind", "Description"})
+		t.AppendRows(flags)
+		t.AppendSeparator()
+		t.AppendRows(args)
+		c.Println(t.Render())
+	})
+}
+
+// displayValue formats a value for the show tables. Lists are shown as [a b].
+func displayValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	rv := reflect.ValueOf(v)
+	if !isList(v) {
+		return fmt.Sprintf("%v", v)
+	}
+	elems := make([]string, rv.Len())
+	for i := range elems {
+		elems[i] = fmt.Sprintf("%v", rv.Index(i))
+	}
+	return "[" + strings.Join(elems, " ") + "]"
+}
+
+// displayItem formats the value of a flag or arg for the show tables.
+// Lists read from a file are shown by the file and the number of elements.
+func displayItem(v interface{}, file string) string {
+	if len(file) > 0 {
+		n := 0
+		if v != nil && isList(v) {
+			n = reflect.ValueOf(v).Len()
+		}
+		return fmt.Sprintf("%s%s (%d elements)", listFilePrefix, file, n)
+	}
+	return displayValue(v)
+}
+
+// optionType returns the short type name of a flag or arg.
+func optionType(helpArgs string) string {
+	return strings.SplitN(helpArgs, ",", 2)[0]
+}
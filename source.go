@@ -0,0 +1,149 @@
+package jishell
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Sources of flag values, from the highest to the lowest precedence.
+const (
+	SourceCLI     = "cli"
+	SourceSet     = "set"
+	SourceGlobal  = "global"
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceDefault = "default"
+)
+
+type flagOptionFunc func(i *flagItem)
+
+func (o flagOptionFunc) applyFlag(i *flagItem) {
+	o(i)
+}
+
+// Env takes the value of the flag from the environment variable,
+// if the flag has not been set otherwise. Empty values are ignored.
+func Env(name string) FlagOption {
+	return flagOptionFunc(func(i *flagItem) {
+		i.env = name
+	})
+}
+
+// overridable returns true, if the value may be replaced by setf or setg,
+// i.e. it is the default value or has been taken from the environment or config file.
+func (i *FlagMapItem) overridable() bool {
+	return i.IsDefault || i.Source == SourceEnv || i.Source == SourceFile
+}
+
+// setDefault sets the value of a flag, which has not been passed. The value is taken
+// from the environment variable of the flag, the config file or the default value.
+func (f *Flags) setDefault(i *flagItem, res FlagMap) error {
+	value, source, ok := f.sourceValue(i)
+	if ok {
+		tmp := make(FlagMap)
+		for _, p := range f.parsers {
+			_, parsed, err := p("--"+i.Long, value, nil, tmp)
+			if err == nil && !parsed {
+				continue
+			} else if err == nil {
+				err = i.rules.check("flag '"+i.Long+"'", tmp[i.Long].Value)
+			}
+			if err != nil {
+				return fmt.Errorf("%s value of flag '%s': %v", source, i.Long, err)
+			}
+			tmp[i.Long].Source = source
+			res[i.Long] = tmp[i.Long]
+			return nil
+		}
+	}
+
+	df, ok := f.defaults[i.Long]
+	if !ok {
+		return fmt.Errorf("invalid flag: missing default function: %s", i.Long)
+	}
+	df(res)
+	res[i.Long].Source = SourceDefault
+	return nil
+}
+
+// reset sets the flag to the value it has, if it has not been set.
+// Invalid values of the environment or config file are ignored.
+func (f *Flags) reset(long string, res FlagMap) {
+	for _, i := range f.list {
+		if i.Long != long {
+			continue
+		}
+		if f.setDefault(i, res) != nil {
+			f.defaults[long](res)
+			res[long].Source = SourceDefault
+		}
+		return
+	}
+}
+
+// sourceValue returns the value of the flag from the environment or the config file.
+// Empty values are ignored.
+func (f *Flags) sourceValue(i *flagItem) (value, source string, ok bool) {
+	if len(i.env) > 0 {
+		value, ok = os.LookupEnv(i.env)
+		if ok && len(value) > 0 {
+			return value, SourceEnv, true
+		}
+	}
+	if f.fileValue != nil {
+		value, ok = f.fileValue(i.Long)
+		if ok && len(value) > 0 {
+			return value, SourceFile, true
+		}
+	}
+	return "", "", false
+}
+
+// loadConfigFile reads the config file configured in the config, if present.
+// The flags of the app and of all commands take their values from it.
+func (a *App) loadConfigFile() error {
+	if len(a.config.ConfigFile) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(a.config.ConfigFile); os.IsNotExist(err) {
+		return nil
+	}
+	v := viper.New()
+	v.SetConfigFile(a.config.ConfigFile)
+	err := v.ReadInConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	a.configFile = v
+	a.flags.fileValue = a.configValue(nil)
+	return nil
+}
+
+// bindConfigFile lets the flags of all commands take their values from the config file.
+func (a *App) bindConfigFile() {
+	if a.configFile == nil {
+		return
+	}
+	a.commands.walk(func(cmd *Command) {
+		cmd.flags.fileValue = a.configValue(cmd)
+	})
+}
+
+// configValue returns the lookup of the flag values of the command in the config file.
+// The keys are the command path and the flag, e.g. 'cdn.check.timeout'.
+// The flags of the app are top level keys.
+func (a *App) configValue(cmd *Command) func(long string) (string, bool) {
+	return func(long string) (string, bool) {
+		key := long
+		if cmd != nil {
+			key = strings.ReplaceAll(strings.TrimPrefix(cmd.fullPath(), "/"), "/", ".") + "." + long
+		}
+		if !a.configFile.IsSet(key) {
+			return "", false
+		}
+		return formatOptionValue(a.configFile.Get(key)), true
+	}
+}
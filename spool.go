@@ -0,0 +1,97 @@
+package jishell
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ansiRegexp matches the escape sequences of colored output.
+var ansiRegexp = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// spooler writes a transcript of the shell session to a file.
+// Every line is prefixed with a timestamp and color codes are removed.
+type spooler struct {
+	mu      sync.Mutex
+	f       *os.File
+	path    string
+	midLine bool // The last write did not end with a newline.
+}
+
+// Write writes the data to the spool file, if spooling is active.
+func (s *spooler) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return len(p), nil
+	}
+
+	var (
+		b    bytes.Buffer
+		data = ansiRegexp.ReplaceAll(p, nil)
+		ts   = time.Now().Format("[2006-01-02 15:04:05] ")
+	)
+	for len(data) > 0 {
+		if !s.midLine {
+			b.WriteString(ts)
+		}
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			b.Write(data)
+			s.midLine = true
+			break
+		}
+		b.Write(data[:i+1])
+		data = data[i+1:]
+		s.midLine = false
+	}
+	_, err := s.f.Write(b.Bytes())
+	return len(p), err
+}
+
+// active returns the path of the spool file or an empty string.
+func (s *spooler) active() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.path
+}
+
+// start opens the spool file. A running spool is stopped first.
+func (s *spooler) start(path string, appendMode bool) error {
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendMode {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flag, 0600)
+	if err != nil {
+		return err
+	}
+	err = s.stop()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.f, s.path, s.midLine = f, path, false
+	return nil
+}
+
+// stop closes the spool file, if spooling is active.
+func (s *spooler) stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	if s.midLine {
+		fmt.Fprintln(s.f)
+	}
+	err := s.f.Close()
+	s.f, s.path = nil, ""
+	return err
+}
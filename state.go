@@ -0,0 +1,177 @@
+package jishell
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// stateFile is the on-disk format written by the save command.
+type stateFile struct {
+	// Globals holds the global option values set by setg.
+	Globals map[string]string `json:"globals,omitempty"`
+
+	// Commands maps the full path of a command to its option state.
+	Commands map[string]*commandState `json:"commands"`
+}
+
+// commandState holds the flag and arg values of a single command
+// in the same notation as accepted by setf and seta.
+type commandState struct {
+	Flags map[string]string `json:"flags,omitempty"`
+	Args  map[string]string `json:"args,omitempty"`
+}
+
+// saveState writes the option state of every command to the file.
+func (a *App) saveState(path string) error {
+	state := &stateFile{
+		Globals:  a.Globals(),
+		Commands: make(map[string]*commandState),
+	}
+	a.commands.walk(func(cmd *Command) {
+		if cmd.isBuiltin {
+			return
+		}
+		cs := &commandState{
+			Flags: make(map[string]string),
+			Args:  make(map[string]string),
+		}
+		for _, v := range cmd.flags.list {
+			item, ok := cmd.jflagMaps[v.Long]
+			if v.Long == "help" || !ok || item.overridable() || cmd.globalOpts[v.Long] {
+				continue
+			}
+			cs.Flags[v.Long] = formatOptionValue(item.Value)
+		}
+		for _, v := range cmd.args.list {
+			if item, ok := cmd.jargMaps[v.Name]; ok && !cmd.globalOpts[v.Name] {
+				cs.Args[v.Name] = formatOptionValue(item.Value)
+			}
+		}
+		if len(cs.Flags) > 0 || len(cs.Args) > 0 {
+			state.Commands[cmd.fullPath()] = cs
+		}
+	})
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// loadState restores the option state of every command saved in the file.
+// The values are passed through the flag and arg parsers of the commands,
+// so that invalid values are rejected. Commands that no longer exist are reported.
+func (a *App) loadState(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	state := &stateFile{}
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return fmt.Errorf("invalid state file '%s': %v", path, err)
+	}
+
+	if len(state.Globals) > 0 && a.globals == nil {
+		a.globals = make(map[string]string)
+	}
+	for k, v := range state.Globals {
+		a.globals[k] = v
+	}
+
+	var errs []string
+	for cmdPath, cs := range state.Commands {
+		cmd := a.commands.getPath(cmdPath)
+		if cmd == nil {
+			errs = append(errs, fmt.Sprintf("%s: command not found", cmdPath))
+			continue
+		}
+		// Reset the current state, so that list values are not appended.
+		cmd.jflagMaps = nil
+		cmd.jargMaps = nil
+		cmd.globalOpts = nil
+		err = cmd.initOptions()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", cmdPath, err))
+			continue
+		}
+		for _, name := range sortedKeys(cs.Flags) {
+			delete(cmd.jflagMaps, name)
+			err = cmd.setFlag(name, cs.Flags[name])
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", cmdPath, err))
+			}
+		}
+		for _, name := range sortedKeys(cs.Args) {
+			err = cmd.setArg(name, cs.Args[name])
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", cmdPath, err))
+			}
+		}
+		err = a.applyGlobals(cmd)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("failed to load state from '%s':\n  %s", path, strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// autoLoadState loads the state file configured in the config, if present.
+func (a *App) autoLoadState() error {
+	if len(a.config.StateFile) == 0 {
+		return nil
+	}
+	err := a.loadState(a.config.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// formatOptionValue formats the value of a flag or arg, so that it can
+// be parsed again by its parser. List elements are separated by commas.
+func formatOptionValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	rv := reflect.ValueOf(v)
+	if !isList(v) {
+		return fmt.Sprintf("%v", v)
+	}
+	elems := make([]string, rv.Len())
+	for i := range elems {
+		elems[i] = quoteListElem(fmt.Sprintf("%v", rv.Index(i).Interface()))
+	}
+	return strings.Join(elems, ",")
+}
+
+// quoteListElem quotes a list element if it would otherwise be split
+// or unescaped by the list parsers, or read as list file.
+func quoteListElem(s string) string {
+	isFile := strings.HasPrefix(s, listFilePrefix) || strings.HasPrefix(s, listFileURLPrefix)
+	if len(s) > 0 && !strings.ContainsAny(s, ",\"'\\") && !isFile {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,230 @@
+package jishell
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	shlex "github.com/chroblert/go-shlex"
+)
+
+// FlagOption can be supplied to modify a flag.
+type FlagOption interface {
+	applyFlag(i *flagItem)
+}
+
+// ValueOption restricts the values of a flag or an argument.
+// The rules are checked on the command line and by setf and seta.
+// For lists, every element must match.
+type ValueOption func(r *valueRules)
+
+func (o ValueOption) applyArg(i *argItem) {
+	o(&i.rules)
+}
+
+func (o ValueOption) applyFlag(i *flagItem) {
+	o(&i.rules)
+}
+
+// Choices restricts the value to the given choices.
+// The choices are also offered by the completion.
+func Choices(choices ...string) ValueOption {
+	if len(choices) == 0 {
+		panic("choices must not be empty")
+	}
+	return func(r *valueRules) {
+		r.choices = choices
+	}
+}
+
+// Range restricts a numeric value to the range from min to max, inclusive.
+func Range(min, max float64) ValueOption {
+	if max < min {
+		panic("max must not be less than min")
+	}
+	return func(r *valueRules) {
+		r.hasRange = true
+		r.min, r.max = min, max
+	}
+}
+
+// Pattern restricts the value to strings matching the regular expression.
+// Panics if the expression is invalid.
+func Pattern(expr string) ValueOption {
+	re := regexp.MustCompile(expr)
+	return func(r *valueRules) {
+		r.pattern = re
+	}
+}
+
+// Validate checks the value with a custom function.
+// The function receives the parsed value, e.g. a []string for string lists.
+func Validate(fn func(v interface{}) error) ValueOption {
+	if fn == nil {
+		panic("nil validate function not allowed")
+	}
+	return func(r *valueRules) {
+		r.validators = append(r.validators, fn)
+	}
+}
+
+// CompleteFunc returns the completions of a flag or argument value.
+// The context holds the flag and arg values of the command set by setf and seta.
+// For lists, the prefix is the last element typed after the comma.
+type CompleteFunc func(c *Context, prefix string) []string
+
+// Complete offers the values by the completion. Unlike Choices,
+// other values are allowed.
+func Complete(values ...string) ValueOption {
+	return func(r *valueRules) {
+		r.completions = append(r.completions, values...)
+	}
+}
+
+// CompleteWith offers the values returned by the function by the completion,
+// e.g. the hosts of a previous scan.
+func CompleteWith(fn CompleteFunc) ValueOption {
+	if fn == nil {
+		panic("nil complete function not allowed")
+	}
+	return func(r *valueRules) {
+		r.completeFuncs = append(r.completeFuncs, fn)
+	}
+}
+
+// valueRules are the restrictions of a flag or an argument.
+type valueRules struct {
+	choices    []string
+	hasRange   bool
+	min, max   float64
+	pattern    *regexp.Regexp
+	validators []func(v interface{}) error
+
+	completions   []string
+	completeFuncs []CompleteFunc
+
+	pathKind  int
+	pathExist int
+
+	noListFile bool
+}
+
+// check validates the value. The name is used for error messages.
+func (r *valueRules) check(name string, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	if isList(v) {
+		rv := reflect.ValueOf(v)
+		for i := 0; i < rv.Len(); i++ {
+			err := r.checkElem(name, rv.Index(i).Interface())
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err := r.checkElem(name, v)
+		if err != nil {
+			return err
+		}
+	}
+	for _, fn := range r.validators {
+		err := fn(v)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// checkElem validates a single value or list element.
+func (r *valueRules) checkElem(name string, v interface{}) error {
+	s := fmt.Sprintf("%v", v)
+	if len(r.choices) > 0 {
+		found := false
+		for _, c := range r.choices {
+			if c == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid value '%s' for %s: must be one of %s", s, name, strings.Join(r.choices, ", "))
+		}
+	}
+	if r.hasRange {
+		f, ok := toFloat(v)
+		if !ok {
+			return fmt.Errorf("invalid value '%s' for %s: not a number", s, name)
+		} else if f < r.min || f > r.max {
+			return fmt.Errorf("invalid value '%s' for %s: must be between %v and %v", s, name, r.min, r.max)
+		}
+	}
+	if r.pattern != nil && !r.pattern.MatchString(s) {
+		return fmt.Errorf("invalid value '%s' for %s: must match %s", s, name, r.pattern)
+	}
+	if r.pathKind != 0 || r.pathExist != 0 {
+		return r.checkPath(name, s)
+	}
+	return nil
+}
+
+// String describes the rules for the help output.
+func (r *valueRules) String() string {
+	var parts []string
+	if len(r.choices) > 0 {
+		parts = append(parts, "choices: "+strings.Join(r.choices, ", "))
+	}
+	if r.hasRange {
+		parts = append(parts, fmt.Sprintf("range: %v-%v", r.min, r.max))
+	}
+	if r.pattern != nil {
+		parts = append(parts, "pattern: "+r.pattern.String())
+	}
+	if r.pathExist > 0 {
+		parts = append(parts, "must exist")
+	} else if r.pathExist < 0 {
+		parts = append(parts, "must not exist")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(parts, "; ") + ")"
+}
+
+// unquotedValue returns string values passed on the command line without
+// their quotes, which are only removed right before the command runs, e.g. "tcp".
+func unquotedValue(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok || len(s) == 0 || s[0] != '"' && s[0] != '\'' {
+		return v
+	}
+	if w, err := shlex.Split(s, true, false); err == nil && len(w) == 1 {
+		return w[0]
+	}
+	return v
+}
+
+// isList returns true, if the value is a list. Values such as net.IP,
+// which are slices with a string form, are single values.
+func isList(v interface{}) bool {
+	if _, ok := v.(fmt.Stringer); ok {
+		return false
+	}
+	return reflect.ValueOf(v).Kind() == reflect.Slice
+}
+
+// toFloat converts a numeric value to float64.
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
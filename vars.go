@@ -0,0 +1,155 @@
+package jishell
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var varNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SetVar sets a user variable, which can be referenced as $name or ${name} in the shell.
+func (a *App) SetVar(name, value string) error {
+	if !varNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid variable name: %s", name)
+	}
+	if a.vars == nil {
+		a.vars = make(map[string]string)
+	}
+	a.vars[name] = value
+	return nil
+}
+
+// UnsetVar removes a user variable.
+func (a *App) UnsetVar(name string) {
+	delete(a.vars, name)
+}
+
+// Var returns the value of a user variable or of an environment variable.
+// The special variables '?' and '_' hold the status and the last argument of
+// the previous command.
+func (a *App) Var(name string) (string, bool) {
+	switch name {
+	case "?":
+		if a.lastErr != nil {
+			return "1", true
+		}
+		return "0", true
+	case "_":
+		return a.lastArg, true
+	}
+	if v, ok := a.vars[name]; ok {
+		return v, true
+	}
+	return os.LookupEnv(name)
+}
+
+// expandVars replaces $name and ${name} in the line by the variable values.
+// $results[n].path refers to a part of a previous result.
+// Single quoted text and escaped dollar signs are kept literally.
+// Unknown variables are replaced by an empty string.
+func (a *App) expandVars(line string) (string, error) {
+	var (
+		b     strings.Builder
+		quote byte
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && quote != '\'' && i+1 < len(line):
+			b.WriteByte(c)
+			b.WriteByte(line[i+1])
+			i++
+			continue
+		case quote == '\'':
+			if c == quote {
+				quote = 0
+			}
+			b.WriteByte(c)
+			continue
+		case c == '\'' && quote == 0:
+			quote = c
+			b.WriteByte(c)
+			continue
+		case c == '"':
+			if quote == 0 {
+				quote = c
+			} else {
+				quote = 0
+			}
+			b.WriteByte(c)
+			continue
+		case c != '$' || i+1 == len(line):
+			b.WriteByte(c)
+			continue
+		}
+
+		// Parse the variable name.
+		var name string
+		next := line[i+1]
+		switch {
+		case next == '?' || next == '_' && !isVarChar(line, i+2):
+			name = string(next)
+			i++
+		case next == '{':
+			end := strings.IndexByte(line[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("missing '}' for variable")
+			}
+			name = line[i+2 : i+2+end]
+			i += 2 + end
+		case isResultRef(line, i+1):
+			end := strings.IndexByte(line[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("missing ']' for result reference")
+			}
+			end += i + 2
+			for end < len(line) && isResultPathChar(line[end]) {
+				end++
+			}
+			name = line[i+1 : end]
+			i = end - 1
+		case next == '_' || next >= 'a' && next <= 'z' || next >= 'A' && next <= 'Z':
+			end := i + 1
+			for isVarChar(line, end) {
+				end++
+			}
+			name = line[i+1 : end]
+			i = end - 1
+		default:
+			b.WriteByte(c)
+			continue
+		}
+		value, err := a.lookupVar(name)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(name, "results[") {
+			// Results are inserted as one word.
+			value = quoteResultValue(value, quote)
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+// lookupVar returns the value for a variable reference.
+func (a *App) lookupVar(name string) (string, error) {
+	if strings.HasPrefix(name, "results[") {
+		return a.resultValue(name)
+	}
+	if name != "?" && name != "_" && !varNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid variable name: %s", name)
+	}
+	v, _ := a.Var(name)
+	return v, nil
+}
+
+func isVarChar(s string, i int) bool {
+	if i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}